@@ -0,0 +1,130 @@
+package uinput
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The binary session format is meant for long soak tests where text formats
+// grow too large. It starts with binaryLogMagic, a version byte and the JSON
+// device description prefixed by its length, followed by one record per event:
+//
+//	varint   microseconds since the previous event
+//	uvarint  type
+//	uvarint  code
+//	varint   value
+//
+// Most events fit in four bytes, against 24 for a raw input_event.
+const (
+	binaryLogMagic   = "UIEVLOG"
+	binaryLogVersion = 1
+)
+
+type BinarySessionWriter struct {
+	w        *bufio.Writer
+	previous int64
+	buffer   [4 * binary.MaxVarintLen64]byte
+}
+
+// NewBinarySessionWriter buffers its output; call Flush once the session ends.
+func NewBinarySessionWriter(w io.Writer) *BinarySessionWriter {
+	return &BinarySessionWriter{w: bufio.NewWriter(w), previous: -1}
+}
+
+func (self *BinarySessionWriter) WriteDescription(description DeviceDescription) error {
+	header, err := json.Marshal(description)
+	if err != nil {
+		return err
+	}
+	self.w.WriteString(binaryLogMagic)
+	self.w.WriteByte(binaryLogVersion)
+	length := binary.PutUvarint(self.buffer[:], uint64(len(header)))
+	self.w.Write(self.buffer[:length])
+	_, err = self.w.Write(header)
+	return err
+}
+
+func (self *BinarySessionWriter) WriteEvent(event InputEvent) error {
+	micros := event.Time.Nano() / 1e3
+	if self.previous < 0 {
+		self.previous = micros
+	}
+	length := binary.PutVarint(self.buffer[:], micros-self.previous)
+	length += binary.PutUvarint(self.buffer[length:], uint64(event.Type))
+	length += binary.PutUvarint(self.buffer[length:], uint64(event.Code))
+	length += binary.PutVarint(self.buffer[length:], int64(event.Value))
+	self.previous = micros
+	_, err := self.w.Write(self.buffer[:length])
+	return err
+}
+
+func (self *BinarySessionWriter) Flush() error {
+	return self.w.Flush()
+}
+
+type BinarySessionReader struct {
+	r      *bufio.Reader
+	micros int64
+}
+
+func NewBinarySessionReader(r io.Reader) *BinarySessionReader {
+	return &BinarySessionReader{r: bufio.NewReader(r)}
+}
+
+func (self *BinarySessionReader) ReadDescription() (description DeviceDescription, err error) {
+	magic := make([]byte, len(binaryLogMagic)+1)
+	if _, err = io.ReadFull(self.r, magic); err != nil {
+		return description, fmt.Errorf("[error] failed to read binary session header: %v", err)
+	}
+	if string(magic[:len(binaryLogMagic)]) != binaryLogMagic {
+		return description, fmt.Errorf("[error] not a binary session log")
+	}
+	if version := magic[len(binaryLogMagic)]; version != binaryLogVersion {
+		return description, fmt.Errorf("[error] unsupported binary session version %d", version)
+	}
+	length, err := binary.ReadUvarint(self.r)
+	if err != nil {
+		return description, fmt.Errorf("[error] failed to read binary session header: %v", err)
+	}
+	header := make([]byte, length)
+	if _, err = io.ReadFull(self.r, header); err != nil {
+		return description, fmt.Errorf("[error] failed to read binary session header: %v", err)
+	}
+	err = json.Unmarshal(header, &description)
+	return description, err
+}
+
+func (self *BinarySessionReader) ReadEvent() (event InputEvent, err error) {
+	// NOTE: A clean end of the log is only possible between records.
+	delta, err := binary.ReadVarint(self.r)
+	if err != nil {
+		return event, err
+	}
+	eventType, err := binary.ReadUvarint(self.r)
+	if err != nil {
+		return event, truncatedRecord(err)
+	}
+	code, err := binary.ReadUvarint(self.r)
+	if err != nil {
+		return event, truncatedRecord(err)
+	}
+	value, err := binary.ReadVarint(self.r)
+	if err != nil {
+		return event, truncatedRecord(err)
+	}
+	self.micros += delta
+	event.Time = NsecToTimeval(self.micros * 1e3)
+	event.Type, event.Code, event.Value = uint16(eventType), uint16(code), int32(value)
+	return event, nil
+}
+
+func truncatedRecord(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("[error] truncated binary session record: %v", err)
+}
@@ -0,0 +1,139 @@
+package uinput
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClickTiming holds the delays used by the multi-click helpers. Toolkits
+// typically group clicks into a double click when they land within 400-500ms
+// of each other (GTK defaults to 400ms, Windows to 500ms), so the defaults
+// stay well inside that window while still giving each press a measurable
+// duration; a zero-length press is ignored or misread by many applications.
+type ClickTiming struct {
+	Hold     time.Duration // time a button stays down for a single click
+	Interval time.Duration // time between a release and the next press
+}
+
+var DefaultClickTiming = ClickTiming{
+	Hold:     40 * time.Millisecond,
+	Interval: 80 * time.Millisecond,
+}
+
+// DragOptions controls the motion emitted by Drag. Any field left at zero
+// falls back to the value in DefaultDragOptions.
+type DragOptions struct {
+	Steps     int           // intermediate motion events between from and to
+	StepDelay time.Duration // delay between intermediate motion events
+	Hold      time.Duration // delay between the press and the first motion
+	Settle    time.Duration // delay at the destination before the release
+}
+
+// NOTE: Drag sources only start a drag once the pointer has moved past a
+// threshold (8px in GTK, 4px in Qt) while the button is held, and several of
+// them ignore a single large jump; so motion is spread over many small steps.
+var DefaultDragOptions = DragOptions{
+	Steps:     20,
+	StepDelay: 10 * time.Millisecond,
+	Hold:      100 * time.Millisecond,
+	Settle:    100 * time.Millisecond,
+}
+
+func (self DragOptions) withDefaults() DragOptions {
+	if self.Steps <= 0 {
+		self.Steps = DefaultDragOptions.Steps
+	}
+	if self.StepDelay == 0 {
+		self.StepDelay = DefaultDragOptions.StepDelay
+	}
+	if self.Hold == 0 {
+		self.Hold = DefaultDragOptions.Hold
+	}
+	if self.Settle == 0 {
+		self.Settle = DefaultDragOptions.Settle
+	}
+	return self
+}
+
+func (self *Device) clickTiming() ClickTiming {
+	timing := self.ClickTiming
+	if timing.Hold == 0 {
+		timing.Hold = DefaultClickTiming.Hold
+	}
+	if timing.Interval == 0 {
+		timing.Interval = DefaultClickTiming.Interval
+	}
+	return timing
+}
+
+func (self *Device) DoubleClick(buttonType ButtonType) error {
+	return self.MultiClick(buttonType, 2)
+}
+
+func (self *Device) TripleClick(buttonType ButtonType) error {
+	return self.MultiClick(buttonType, 3)
+}
+
+// MultiClick clicks the button count times using the device ClickTiming, so
+// the clicks are grouped together by the receiving toolkit.
+func (self *Device) MultiClick(buttonType ButtonType, count int) error {
+	if count < 1 {
+		return fmt.Errorf("[error] invalid click count %d", count)
+	}
+	timing := self.clickTiming()
+	for click := 0; click < count; click++ {
+		if click > 0 {
+			self.clock().Sleep(timing.Interval)
+		}
+		if err := self.ClickAndHold(buttonType, timing.Hold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *Device) ClickAndHold(buttonType ButtonType, duration time.Duration) error {
+	if err := self.PressButton(buttonType); err != nil {
+		return err
+	}
+	self.clock().Sleep(duration)
+	return self.ReleaseButton(buttonType)
+}
+
+// Drag presses the button at from, moves to to in small steps and releases it
+// there. Devices reporting absolute positions (tablets and touchpads) treat
+// from and to as coordinates; a relative mouse treats from as the distance to
+// move before pressing and to as a position relative to where it started.
+func (self *Device) Drag(buttonType ButtonType, from, to Point, options DragOptions) error {
+	options = options.withDefaults()
+	moveTo := self.AbsoluteMoveTo
+	if self.Type == Mouse {
+		current := Point{}
+		moveTo = func(next Point) error {
+			delta := Point{X: next.X - current.X, Y: next.Y - current.Y}
+			current = next
+			return self.RelativeMove(delta)
+		}
+	}
+	if err := moveTo(from); err != nil {
+		return err
+	}
+	if err := self.PressButton(buttonType); err != nil {
+		return err
+	}
+	self.clock().Sleep(options.Hold)
+	steps := int64(options.Steps)
+	for step := int64(1); step <= steps; step++ {
+		next := Point{
+			X: from.X + int32(int64(to.X-from.X)*step/steps),
+			Y: from.Y + int32(int64(to.Y-from.Y)*step/steps),
+		}
+		if err := moveTo(next); err != nil {
+			self.ReleaseButton(buttonType)
+			return err
+		}
+		self.clock().Sleep(options.StepDelay)
+	}
+	self.clock().Sleep(options.Settle)
+	return self.ReleaseButton(buttonType)
+}
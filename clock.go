@@ -0,0 +1,150 @@
+package uinput
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time to code whose behaviour depends on timing, so a
+// deterministic clock can stand in for the system one.
+type Clock interface {
+	Now() time.Time
+	Sleep(time.Duration)
+	// AfterFunc calls f once duration has passed, never before returning.
+	AfterFunc(duration time.Duration, f func()) Timer
+}
+
+// Timer is a call scheduled with AfterFunc; Stop cancels it, telling whether
+// it was still pending.
+type Timer interface {
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(duration time.Duration) { time.Sleep(duration) }
+
+func (systemClock) AfterFunc(duration time.Duration, f func()) Timer {
+	return time.AfterFunc(duration, f)
+}
+
+// SystemClock is the wall clock, the default of everything taking a Clock.
+var SystemClock Clock = systemClock{}
+
+// sleepContext sleeps on clock for duration, or until ctx is done.
+// NOTE: Only the system clock is waited on along with ctx; other clocks, such
+// as FakeClock, sleep without blocking and ctx is checked before.
+func sleepContext(ctx context.Context, clock Clock, duration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return nil
+	}
+	if clock != SystemClock {
+		clock.Sleep(duration)
+		return nil
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// FakeClock is a Clock that only moves when told to, so recorded sessions and
+// timing dependent helpers give the same result on every run. Sleeping on it
+// advances it at once instead of blocking, and the calls scheduled with
+// AfterFunc run, in order, as it moves past them.
+type FakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *FakeClock
+	at      time.Time
+	f       func()
+	pending bool
+}
+
+// NewFakeClock returns a FakeClock stopped at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (self *FakeClock) Now() time.Time {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.now
+}
+
+func (self *FakeClock) Sleep(duration time.Duration) {
+	self.Advance(duration)
+}
+
+// AfterFunc schedules f for when the clock gets duration past now.
+func (self *FakeClock) AfterFunc(duration time.Duration, f func()) Timer {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	timer := &fakeTimer{clock: self, at: self.now.Add(duration), f: f, pending: true}
+	self.timers = append(self.timers, timer)
+	return timer
+}
+
+// Advance moves the clock forward by duration.
+func (self *FakeClock) Advance(duration time.Duration) {
+	self.mutex.Lock()
+	if duration > 0 {
+		self.now = self.now.Add(duration)
+	}
+	self.fire()
+}
+
+// Set moves the clock to now.
+func (self *FakeClock) Set(now time.Time) {
+	self.mutex.Lock()
+	self.now = now
+	self.fire()
+}
+
+// fire runs the timers due, unlocking the mutex taken by the caller.
+func (self *FakeClock) fire() {
+	var due []*fakeTimer
+	pending := self.timers[:0]
+	for _, timer := range self.timers {
+		if !timer.pending {
+			continue
+		}
+		if timer.at.After(self.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.pending = false
+		due = append(due, timer)
+	}
+	self.timers = pending
+	// NOTE: The calls run before Advance or Set return, outside of the mutex
+	// so they may use the clock themselves.
+	self.mutex.Unlock()
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, timer := range due {
+		timer.f()
+	}
+}
+
+func (self *fakeTimer) Stop() bool {
+	self.clock.mutex.Lock()
+	defer self.clock.mutex.Unlock()
+	pending := self.pending
+	self.pending = false
+	return pending
+}
@@ -0,0 +1,245 @@
+package uinput
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var clockStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// recordingDevice returns a device of the type on a FakeClock, writing its
+// events into a file instead of uinput, and a function reading them back as
+// "+40ms BTN_LEFT released", synchronization events left out.
+func recordingDevice(t *testing.T, deviceType DeviceType) (*Device, *FakeClock, func() []string) {
+	t.Helper()
+	file, err := os.CreateTemp(t.TempDir(), "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	clock := NewFakeClock(clockStart)
+	device := &Device{Type: deviceType, FD: file, Clock: clock}
+	if deviceType == Keyboard {
+		device.Keyboard = newVirtualKeyboard()
+	}
+	events := func() []string {
+		t.Helper()
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		var written []string
+		for {
+			event, err := NativeEventLayout.Read(file)
+			if err == io.EOF {
+				return written
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if event.Type != EV_SYN.Code() {
+				written = append(written, fmt.Sprintf("+%v %v", event.Timestamp().Sub(clockStart), FormatEvent(event)))
+			}
+		}
+	}
+	return device, clock, events
+}
+
+func TestFakeClockTimestamps(t *testing.T) {
+	device, clock, events := recordingDevice(t, Keyboard)
+	if err := device.PressKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(5*time.Millisecond + 1500*time.Nanosecond)
+	if err := device.ReleaseKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: input_event times only hold microseconds, rounded up.
+	want := []string{"+0s KEY_A pressed", "+5.002ms KEY_A released"}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+}
+
+func TestFakeClockSynchronizationStamps(t *testing.T) {
+	device, clock, _ := recordingDevice(t, Keyboard)
+	clock.Advance(time.Hour)
+	if err := device.Tap(KEY_B); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := device.FD.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		event, err := NativeEventLayout.Read(device.FD)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if !event.Timestamp().Equal(clockStart.Add(time.Hour)) {
+			t.Errorf("%v stamped %v, want %v", FormatEvent(event), event.Timestamp(), clockStart.Add(time.Hour))
+		}
+	}
+}
+
+func TestMultiClickTiming(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		timing ClickTiming
+		count  int
+		want   []string
+	}{
+		{
+			name:  "default timing",
+			count: 2,
+			want: []string{
+				"+0s BTN_LEFT pressed", "+40ms BTN_LEFT released",
+				"+120ms BTN_LEFT pressed", "+160ms BTN_LEFT released",
+			},
+		},
+		{
+			name:   "custom timing",
+			timing: ClickTiming{Hold: 10 * time.Millisecond, Interval: 30 * time.Millisecond},
+			count:  3,
+			want: []string{
+				"+0s BTN_LEFT pressed", "+10ms BTN_LEFT released",
+				"+40ms BTN_LEFT pressed", "+50ms BTN_LEFT released",
+				"+80ms BTN_LEFT pressed", "+90ms BTN_LEFT released",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			device, clock, events := recordingDevice(t, Mouse)
+			device.ClickTiming = test.timing
+			if err := device.MultiClick(LeftButton, test.count); err != nil {
+				t.Fatal(err)
+			}
+			if written := events(); !reflect.DeepEqual(written, test.want) {
+				t.Errorf("wrote %q, want %q", written, test.want)
+			}
+			if elapsed, last := clock.Now().Sub(clockStart), test.want[len(test.want)-1]; fmt.Sprintf("+%v BTN_LEFT released", elapsed) != last {
+				t.Errorf("clock at +%v after the last release %q", elapsed, last)
+			}
+		})
+	}
+}
+
+func TestHoldKeyTiming(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		duration time.Duration
+		want     []string
+	}{
+		{
+			name:     "released before the repeat delay",
+			duration: 200 * time.Millisecond,
+			want:     []string{"+0s KEY_A pressed", "+200ms KEY_A released"},
+		},
+		{
+			name:     "repeated at the repeat period",
+			duration: 400 * time.Millisecond,
+			want: []string{
+				"+0s KEY_A pressed",
+				"+250ms KEY_A repeated", "+283ms KEY_A repeated", "+316ms KEY_A repeated", "+349ms KEY_A repeated",
+				"+400ms KEY_A released",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			device, _, events := recordingDevice(t, Keyboard)
+			if err := device.HoldKey(KEY_A, test.duration); err != nil {
+				t.Fatal(err)
+			}
+			if written := events(); !reflect.DeepEqual(written, test.want) {
+				t.Errorf("wrote %q, want %q", written, test.want)
+			}
+		})
+	}
+}
+
+func TestDragTiming(t *testing.T) {
+	device, _, events := recordingDevice(t, Touchpad)
+	err := device.Drag(LeftButton, Point{X: 100, Y: 100}, Point{X: 300, Y: 200}, DragOptions{
+		Steps:     2,
+		StepDelay: 10 * time.Millisecond,
+		Hold:      100 * time.Millisecond,
+		Settle:    50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"+0s ABS_X 100", "+0s ABS_Y 100",
+		"+0s BTN_LEFT pressed",
+		"+100ms ABS_X 200", "+100ms ABS_Y 150",
+		"+110ms ABS_X 300", "+110ms ABS_Y 200",
+		"+170ms BTN_LEFT released",
+	}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+}
+
+func TestMacroWaitTiming(t *testing.T) {
+	device, _, events := recordingDevice(t, Keyboard)
+	macro, err := ParseMacro("press a; wait 250ms; release a\nwait 1s; key b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := macro.Run(context.Background(), device, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"+0s KEY_A pressed", "+250ms KEY_A released", "+1.25s KEY_B pressed", "+1.25s KEY_B released"}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+}
+
+func TestReplayTiming(t *testing.T) {
+	var session bytes.Buffer
+	writer := NewJSONSessionWriter(&session)
+	writer.WriteDescription(evemuDescription)
+	for _, event := range []InputEvent{
+		{Time: Timeval{Sec: 10}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1},
+		{Time: Timeval{Sec: 10}, Type: EV_SYN.Code()},
+		{Time: Timeval{Sec: 10, Usec: 500000}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 0},
+		{Time: Timeval{Sec: 10, Usec: 500000}, Type: EV_SYN.Code()},
+	} {
+		writer.WriteEvent(event)
+	}
+	reader := NewJSONSessionReader(&session)
+	if _, err := reader.ReadDescription(); err != nil {
+		t.Fatal(err)
+	}
+	device, _, events := recordingDevice(t, Keyboard)
+	if err := ReplayOn(context.Background(), device, reader, 2); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"+0s KEY_A pressed", "+250ms KEY_A released"}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+}
+
+func TestTimelineTiming(t *testing.T) {
+	device, clock, events := recordingDevice(t, Keyboard)
+	timeline := &Timeline{Clock: clock}
+	timeline.ReleaseKey(30*time.Millisecond, device, KEY_A)
+	timeline.PressKey(0, device, KEY_A)
+	stats, err := timeline.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"+0s KEY_A pressed", "+30ms KEY_A released"}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+	if len(stats.Drifts) != 2 || stats.Max != 0 {
+		t.Errorf("drifted %v", stats.Drifts)
+	}
+}
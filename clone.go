@@ -0,0 +1,38 @@
+package uinput
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// CloneDevice creates a virtual device identical to the input event device at
+// path, e.g. "/dev/input/event3": same name, id, physical path, properties,
+// event codes, axis ranges and force feedback effect count, as read from the
+// kernel through evdev. An empty name keeps the name of the original device.
+//
+// NOTE: Effects uploaded to the clone are accepted but never played.
+func CloneDevice(path, name string) (VirtualDevice, error) {
+	reader, err := OpenEventReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	description, err := reader.Description()
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		description.Name = name
+	}
+	return description.Connect()
+}
+
+// setPhys sets the physical path reported by the device, which must be done
+// before it is created.
+func (self *Device) setPhys() error {
+	phys := append([]byte(self.Phys), 0)
+	if err := ioctl(self.FD, PhysicalPath.Code(), uintptr(unsafe.Pointer(&phys[0]))); err != nil {
+		return fmt.Errorf("[error] failed to set physical path: %v", err)
+	}
+	return nil
+}
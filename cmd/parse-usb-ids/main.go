@@ -1,19 +1,43 @@
+// parse-usb-ids regenerates the usb ids embedded in the usb-id package from a
+// usb.ids file, such as the one shipped by hwdata:
+//
+//	go run ./cmd/parse-usb-ids -input /usr/share/hwdata/usb.ids -output usb-id/usb.ids.gz
 package main
 
 import (
+	"compress/gzip"
+	"flag"
 	"fmt"
+	"log"
+	"os"
 
-	"github.com/multiverse-os/uinput"
+	usb "github.com/multiverse-os/uinput/usb-id"
 )
 
 func main() {
+	input := flag.String("input", usb.SystemPaths[0], "usb.ids file to parse")
+	output := flag.String("output", "usb-id/usb.ids.gz", "gzipped usb.ids to write")
+	flag.Parse()
 
-	fmt.Printf("uinput-cli")
-	fmt.Printf("==========")
-
-	fmt.Printf("Creating a new test keyboard.\n")
-	keyboard := uinput.NewKeyboard("test-keyboard")
-
-	fmt.Printf("keyboard: %v\n", keyboard)
-
+	database, err := usb.Load(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	compressed, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := database.WriteTo(compressed); err != nil {
+		log.Fatal(err)
+	}
+	if err := compressed.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%v: %d vendors, %d classes, %d usage pages, %d country codes\n",
+		*output, len(database.Vendors), len(database.Classes), len(database.UsagePages), len(database.CountryCodes))
 }
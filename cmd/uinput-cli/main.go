@@ -0,0 +1,10 @@
+// uinput-cli drives virtual input devices and inspects real ones from the
+// command line; it is the uinput command under its former name, see
+// internal/cli for the subcommands.
+package main
+
+import "github.com/multiverse-os/uinput/internal/cli"
+
+func main() {
+	cli.Main("uinput-cli")
+}
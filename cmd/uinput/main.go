@@ -0,0 +1,11 @@
+// uinput drives virtual input devices and inspects real ones from the command
+// line, in place of xdotool, ydotool, evtest and evemu, e.g. uinput doctor
+// when virtual devices cannot be created. See internal/cli for the
+// subcommands.
+package main
+
+import "github.com/multiverse-os/uinput/internal/cli"
+
+func main() {
+	cli.Main("uinput")
+}
@@ -0,0 +1,232 @@
+package uinput
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DeviceConfig describes a virtual device in a file, so test rigs can be set
+// up without recompiling. The same fields are read from YAML, JSON or TOML:
+//
+//	name: test rig gamepad
+//	bus: usb
+//	vendor: 0x045e
+//	product: 0x028e
+//	version: 0x0110
+//	keys: [BTN_SOUTH, BTN_EAST, BTN_START]
+//	axes:
+//	  ABS_X: {min: -32768, max: 32767, fuzz: 16, flat: 128}
+//	  ABS_Y: {min: -32768, max: 32767, fuzz: 16, flat: 128}
+//	force_feedback: ["0x50"] # FF_RUMBLE
+//	ff_effects: 16
+//
+// Event codes and properties are named as in input.h. Ids are numbers, which
+// YAML and TOML take in hex as above, or hex strings such as "045e" in JSON.
+// The bus is a number or a name: pci, isapnp, usb, hil, bluetooth, virtual.
+type DeviceConfig struct {
+	Name       string             `json:"name"`
+	Bus        configNumber       `json:"bus"`
+	Vendor     configNumber       `json:"vendor"`
+	Product    configNumber       `json:"product"`
+	Version    configNumber       `json:"version"`
+	Phys       string             `json:"phys"`
+	Properties []string           `json:"properties"`
+	Keys       []string           `json:"keys"`
+	Relative   []string           `json:"relative"`
+	Axes       map[string]AbsInfo `json:"axes"`
+	Switches   []string           `json:"switches"`
+	LEDs       []string           `json:"leds"`
+	// ForceFeedback are the FF_ codes as numbers, only registered along
+	// with a number of FFEffects the device can hold.
+	ForceFeedback []string `json:"force_feedback"`
+	FFEffects     uint32   `json:"ff_effects"`
+}
+
+// configNumber is a 16 bit id given as a number, or a string in hex such as
+// "045e"; the bus may be a name too.
+type configNumber struct {
+	text   string
+	number bool
+}
+
+var busNames = map[string]BusType{
+	"pci":       PCI,
+	"isapnp":    ISANPN,
+	"usb":       USB,
+	"hil":       HIL,
+	"bluetooth": Bluetooth,
+	"virtual":   Virtual,
+}
+
+func (self *configNumber) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		self.text, self.number = text, false
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("[error] expected a number or a string, found %s", data)
+	}
+	self.text, self.number = number.String(), true
+	return nil
+}
+
+func (self configNumber) uint16(field string) (uint16, error) {
+	text := strings.TrimSpace(self.text)
+	if text == "" {
+		return 0, nil
+	}
+	base := 16
+	if self.number {
+		base = 10
+	} else if bus, ok := busNames[strings.ToLower(text)]; ok && field == "bus" {
+		return bus.Code(), nil
+	}
+	text = strings.TrimPrefix(strings.ToLower(text), "0x")
+	value, err := strconv.ParseUint(text, base, 16)
+	if err != nil {
+		return 0, fmt.Errorf("[error] invalid %v %q", field, self.text)
+	}
+	return uint16(value), nil
+}
+
+// LoadDeviceConfig reads a device config, in the format its extension names:
+// .yaml or .yml, .json or .toml.
+func LoadDeviceConfig(path string) (DeviceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DeviceConfig{}, err
+	}
+	config, err := ParseDeviceConfig(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return DeviceConfig{}, fmt.Errorf("[error] invalid device config %v: %v", path, err)
+	}
+	return config, nil
+}
+
+// ParseDeviceConfig parses a device config in format: yaml, json or toml.
+func ParseDeviceConfig(data []byte, format string) (DeviceConfig, error) {
+	// NOTE: YAML and TOML are decoded generically then read as JSON, so the
+	// three formats share the field names and their validation.
+	var generic interface{}
+	switch format = strings.ToLower(format); format {
+	case "json":
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return DeviceConfig{}, err
+		}
+	case "toml":
+		var table map[string]interface{}
+		if err := toml.Unmarshal(data, &table); err != nil {
+			return DeviceConfig{}, err
+		}
+		generic = table
+	default:
+		return DeviceConfig{}, fmt.Errorf("[error] unknown device config format %q", format)
+	}
+	if format != "json" {
+		var err error
+		if data, err = json.Marshal(generic); err != nil {
+			return DeviceConfig{}, err
+		}
+	}
+	var config DeviceConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return DeviceConfig{}, err
+	}
+	return config, nil
+}
+
+// Description converts the config into the description of a device.
+func (self DeviceConfig) Description() (DeviceDescription, error) {
+	if self.Name == "" {
+		return DeviceDescription{}, fmt.Errorf("[error] device config has no name")
+	}
+	description := DeviceDescription{
+		Name:         self.Name,
+		Phys:         self.Phys,
+		Capabilities: make(map[EventType][]EventCode),
+		Abs:          make(map[EventCode]AbsInfo),
+	}
+	for _, field := range []struct {
+		name   string
+		value  configNumber
+		target *uint16
+	}{
+		{"bus", self.Bus, &description.Id.busType},
+		{"vendor", self.Vendor, &description.Id.vendor},
+		{"product", self.Product, &description.Id.product},
+		{"version", self.Version, &description.Id.version},
+	} {
+		value, err := field.value.uint16(field.name)
+		if err != nil {
+			return DeviceDescription{}, err
+		}
+		*field.target = value
+	}
+	for _, name := range self.Properties {
+		property, err := ParseProperty(name)
+		if err != nil {
+			return DeviceDescription{}, err
+		}
+		description.Properties = append(description.Properties, property)
+	}
+	for _, codes := range []struct {
+		eventType EventType
+		names     []string
+	}{
+		{EV_KEY, self.Keys},
+		{EV_REL, self.Relative},
+		{EV_SW, self.Switches},
+		{EV_LED, self.LEDs},
+		{EV_FF, self.ForceFeedback},
+	} {
+		for _, name := range codes.names {
+			code, err := ParseEventCode(codes.eventType, name)
+			if err != nil {
+				return DeviceDescription{}, err
+			}
+			description.Capabilities[codes.eventType] = append(description.Capabilities[codes.eventType], code)
+		}
+	}
+	for name, info := range self.Axes {
+		axis, err := ParseEventCode(EV_ABS, name)
+		if err != nil {
+			return DeviceDescription{}, err
+		}
+		if info.Minimum > info.Maximum {
+			return DeviceDescription{}, fmt.Errorf("[error] axis %v has a minimum over its maximum", name)
+		}
+		description.Capabilities[EV_ABS] = append(description.Capabilities[EV_ABS], axis)
+		description.Abs[axis] = info
+	}
+	if len(self.ForceFeedback) > 0 && self.FFEffects == 0 {
+		return DeviceDescription{}, fmt.Errorf("[error] force feedback needs ff_effects, the number of effects the device holds")
+	}
+	description.EffectsMax = self.FFEffects
+	return description, nil
+}
+
+// Connect creates the virtual device the config describes.
+func (self DeviceConfig) Connect() (*Device, error) {
+	description, err := self.Description()
+	if err != nil {
+		return nil, err
+	}
+	device, err := description.Connect()
+	if err != nil {
+		return nil, err
+	}
+	return device.(*Device), nil
+}
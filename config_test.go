@@ -0,0 +1,110 @@
+package uinput
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var gamepadAxis = AbsInfo{Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128}
+
+var gamepadDescription = DeviceDescription{
+	Name: "test rig gamepad",
+	Id:   deviceId{busType: USB.Code(), vendor: 0x045e, product: 0x028e, version: 0x0110},
+	Capabilities: map[EventType][]EventCode{
+		EV_KEY: {BTN_SOUTH, BTN_EAST, BTN_START},
+		EV_ABS: {ABS_X, ABS_Y},
+		EV_FF:  {0x50},
+	},
+	Abs:        map[EventCode]AbsInfo{ABS_X: gamepadAxis, ABS_Y: gamepadAxis},
+	EffectsMax: 16,
+}
+
+func TestLoadDeviceConfig(t *testing.T) {
+	for _, path := range []string{"testdata/gamepad.yaml", "testdata/gamepad.json", "testdata/gamepad.toml"} {
+		config, err := LoadDeviceConfig(path)
+		if err != nil {
+			t.Errorf("%v: %v", path, err)
+			continue
+		}
+		description, err := config.Description()
+		if err != nil {
+			t.Errorf("%v: %v", path, err)
+			continue
+		}
+		// NOTE: Axes come from a map, in no particular order.
+		axes := description.Capabilities[EV_ABS]
+		sort.Slice(axes, func(i, j int) bool { return axes[i] < axes[j] })
+		if !reflect.DeepEqual(description, gamepadDescription) {
+			t.Errorf("%v: described as %+v, want %+v", path, description, gamepadDescription)
+		}
+	}
+}
+
+func TestDeviceConfigIds(t *testing.T) {
+	for _, test := range []struct {
+		config string
+		format string
+		id     deviceId
+		err    string
+	}{
+		{config: `{"vendor": 1118}`, format: "json", id: deviceId{vendor: 0x045e}},
+		{config: `{"vendor": "045e"}`, format: "json", id: deviceId{vendor: 0x045e}},
+		{config: `{"vendor": "0x045E"}`, format: "json", id: deviceId{vendor: 0x045e}},
+		{config: `{"bus": "Bluetooth"}`, format: "json", id: deviceId{busType: Bluetooth.Code()}},
+		{config: `{"bus": "0x05"}`, format: "json", id: deviceId{busType: Bluetooth.Code()}},
+		{config: "vendor: 0x045e", format: "yaml", id: deviceId{vendor: 0x045e}},
+		// NOTE: Quoted ids are strings, so in hex even without 0x.
+		{config: `vendor: "1118"`, format: "yaml", id: deviceId{vendor: 0x1118}},
+		{config: "vendor = 1118", format: "toml", id: deviceId{vendor: 0x045e}},
+		{config: `{"vendor": "bluetooth"}`, format: "json", err: `[error] invalid vendor "bluetooth"`},
+		{config: `{"product": 65536}`, format: "json", err: `[error] invalid product "65536"`},
+		{config: `{"version": "-1"}`, format: "json", err: `[error] invalid version "-1"`},
+		{config: `{"bus": 1.5}`, format: "json", err: `[error] invalid bus "1.5"`},
+	} {
+		config, err := ParseDeviceConfig([]byte(test.config), test.format)
+		if err != nil {
+			t.Errorf("%s: %v", test.config, err)
+			continue
+		}
+		config.Name = "ids"
+		description, err := config.Description()
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: described with %v, want %v", test.config, err, test.err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", test.config, err)
+		} else if description.Id != test.id {
+			t.Errorf("%s: id %+v, want %+v", test.config, description.Id, test.id)
+		}
+	}
+}
+
+func TestDeviceConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		config string
+		format string
+		err    string
+	}{
+		{`{"name": "pad", "axes": {"ABS_X": {"min": 10, "max": -10}}}`, "json", "axis ABS_X has a minimum over its maximum"},
+		{"name: pad\nforce_feedback: [\"0x50\"]", "yaml", "force feedback needs ff_effects"},
+		{`{"name": "pad", "buttons": ["BTN_SOUTH"]}`, "json", `unknown field "buttons"`},
+		{"name: pad\nbuttons: [BTN_SOUTH]", "yaml", `unknown field "buttons"`},
+		{"name = \"pad\"\nbuttons = [\"BTN_SOUTH\"]", "toml", `unknown field "buttons"`},
+		{`{"name": "pad", "axes": {"ABS_X": {"min": 0, "max": 1, "step": 1}}}`, "json", `unknown field "step"`},
+		{`{"name": "pad", "keys": ["REL_X"]}`, "json", "REL_X is not an"},
+		{`{"name": "pad", "keys": ["KEY_NOPE"]}`, "json", `code "KEY_NOPE"`},
+		{`{"keys": ["KEY_A"]}`, "json", "device config has no name"},
+		{`name = "pad"`, "ini", `unknown device config format "ini"`},
+	} {
+		config, err := ParseDeviceConfig([]byte(test.config), test.format)
+		if err == nil {
+			_, err = config.Description()
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: failed with %v, want %v", test.config, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package uinput
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The daemon owns one set of virtual devices and runs the macros its clients
+// send over a Unix domain socket, sparing each tool the cost of creating its
+// own devices and the desktop the churn of devices coming and going.
+//
+// The protocol is JSON-RPC 2.0, one request or batch per line. The "run"
+// method runs a macro on the keyboard and the mouse, or on the touchpad with
+// "touch" set, moving to absolute positions:
+//
+//	--> {"jsonrpc": "2.0", "id": 1, "method": "run", "params": {"macro": "key ctrl+l; type \"hello\"; key enter"}}
+//	<-- {"jsonrpc": "2.0", "id": 1, "result": null}
+//
+// Macros failing are answered with the DaemonMacroFailed error code, and
+// clients the daemon refuses with DaemonUnauthorized before it hangs up. A
+// client hanging up, or shutting down its side of the connection, stops the
+// macros it started, so it should wait for the answer to its last request.
+
+// Error codes of the daemon protocol, besides those JSON-RPC defines.
+const (
+	DaemonMacroFailed  = -32000
+	DaemonUnauthorized = -32001
+)
+
+// Error codes JSON-RPC defines.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// DaemonError is an error answered by the daemon.
+type DaemonError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (self *DaemonError) Error() string {
+	return self.Message
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *DaemonError    `json:"error,omitempty"`
+}
+
+// runParams are the params of the "run" method.
+type runParams struct {
+	Macro string `json:"macro"`
+	Touch bool   `json:"touch,omitempty"`
+}
+
+// rpcNull is the id of responses to requests whose id could not be read, and
+// the result of methods returning nothing.
+var rpcNull = json.RawMessage("null")
+
+// Peer identifies the process at the other end of a daemon connection, as
+// reported by the kernel through SO_PEERCRED.
+type Peer struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// Daemon holds the virtual devices shared by its clients.
+type Daemon struct {
+	// Name prefixes the names of the virtual devices.
+	Name string
+	// Screen sizes the touchpad, which is only created when it is set.
+	Screen ScreenSize
+	// Authorize decides whether a client may use the devices. By default only
+	// root and the user running the daemon may.
+	Authorize func(Peer) error
+
+	mutex    sync.Mutex
+	keyboard *Device
+	mouse    *Device
+	touchpad *Device
+}
+
+// DefaultSocketPath is where the daemon listens unless told otherwise, in
+// XDG_RUNTIME_DIR when set.
+func DefaultSocketPath() string {
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		return filepath.Join(runtime, "uinput.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("uinput-%d.sock", os.Getuid()))
+}
+
+// daemonSettleTime gives userspace time to pick up the daemon devices before
+// clients are accepted.
+const daemonSettleTime = 200 * time.Millisecond
+
+func (self *Daemon) connect() error {
+	name := self.Name
+	if name == "" {
+		name = "uinput daemon"
+	}
+	type daemonDevice struct {
+		deviceType DeviceType
+		suffix     string
+		target     **Device
+	}
+	types := []daemonDevice{
+		{Keyboard, "keyboard", &self.keyboard},
+		{Mouse, "mouse", &self.mouse},
+	}
+	if self.Screen.Width > 0 && self.Screen.Height > 0 {
+		types = append(types, daemonDevice{Touchpad, "touchpad", &self.touchpad})
+	}
+	for _, deviceType := range types {
+		device, err := deviceType.deviceType.Create(name + " " + deviceType.suffix)
+		if err != nil {
+			self.disconnect()
+			return err
+		}
+		if deviceType.deviceType == Touchpad {
+			device = device.(*Device).ScreenSize(self.Screen.Width, self.Screen.Height)
+		}
+		if device, err = device.Connect(); err != nil {
+			self.disconnect()
+			return err
+		}
+		*deviceType.target = device.(*Device)
+	}
+	time.Sleep(daemonSettleTime)
+	return nil
+}
+
+func (self *Daemon) disconnect() {
+	for _, device := range []**Device{&self.keyboard, &self.mouse, &self.touchpad} {
+		if *device != nil {
+			(*device).Disconnect()
+			*device = nil
+		}
+	}
+}
+
+// ListenAndServe creates the devices, listens on the socket at path and serves
+// clients until ctx is done, removing the devices and the socket on return.
+func (self *Daemon) ListenAndServe(ctx context.Context, path string) error {
+	// NOTE: A socket left behind by a daemon that did not exit cleanly would
+	// make listening fail.
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return fmt.Errorf("[error] a daemon is already listening on %v", path)
+		}
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("[error] failed to listen on %v: %v", path, err)
+	}
+	defer listener.Close()
+	if err := os.Chmod(path, 0660); err != nil {
+		return fmt.Errorf("[error] failed to set socket permissions: %v", err)
+	}
+	return self.Serve(ctx, listener.(*net.UnixListener))
+}
+
+// Serve creates the devices and serves the clients accepted by listener until
+// ctx is done.
+func (self *Daemon) Serve(ctx context.Context, listener *net.UnixListener) error {
+	if err := self.connect(); err != nil {
+		return err
+	}
+	defer self.disconnect()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	var clients sync.WaitGroup
+	defer clients.Wait()
+	for {
+		conn, err := listener.AcceptUnix()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("[error] failed to accept client: %v", err)
+		}
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			self.serveClient(ctx, conn)
+		}()
+	}
+}
+
+// PeerCredentials returns the credentials of the process at the other end of
+// conn.
+func PeerCredentials(conn *net.UnixConn) (peer Peer, err error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return peer, err
+	}
+	var credentials *syscall.Ucred
+	controlErr := raw.Control(func(fd uintptr) {
+		credentials, err = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if controlErr != nil {
+		return peer, controlErr
+	} else if err != nil {
+		return peer, fmt.Errorf("[error] failed to read peer credentials: %v", err)
+	}
+	return Peer{PID: credentials.Pid, UID: credentials.Uid, GID: credentials.Gid}, nil
+}
+
+func (self *Daemon) authorize(peer Peer) error {
+	if self.Authorize != nil {
+		return self.Authorize(peer)
+	}
+	if peer.UID != 0 && peer.UID != uint32(os.Getuid()) {
+		return fmt.Errorf("[error] user %d may not use this daemon", peer.UID)
+	}
+	return nil
+}
+
+func (self *Daemon) serveClient(ctx context.Context, conn *net.UnixConn) {
+	// NOTE: The connection has its own context, cancelled once the client
+	// hangs up, so a macro it started does not outlive it.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer conn.Close()
+	// NOTE: Closing the connection is the only way to interrupt a blocked read.
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	reply := func(response []byte) bool {
+		_, err := conn.Write(append(response, '\n'))
+		return err == nil
+	}
+	peer, err := PeerCredentials(conn)
+	if err == nil {
+		err = self.authorize(peer)
+	}
+	if err != nil {
+		response, _ := json.Marshal(rpcResponse{JSONRPC: "2.0", ID: rpcNull, Error: &DaemonError{DaemonUnauthorized, err.Error()}})
+		reply(response)
+		return
+	}
+	// NOTE: Requests are read while the previous one runs, so the read loop
+	// notices the client hanging up.
+	lines := make(chan []byte)
+	go func() {
+		defer cancel()
+		defer close(lines)
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(nil, daemonMaxRequest)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for line := range lines {
+		if response := self.handle(ctx, line); response != nil && !reply(response) {
+			return
+		}
+	}
+}
+
+// daemonMaxRequest bounds the length of a request line.
+const daemonMaxRequest = 1 << 20
+
+// handle answers a line of the protocol, a request or a batch of them; nothing
+// is answered to notifications.
+func (self *Daemon) handle(ctx context.Context, line []byte) []byte {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+	if line[0] != '[' {
+		response := self.call(ctx, line)
+		if response == nil {
+			return nil
+		}
+		encoded, _ := json.Marshal(response)
+		return encoded
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		encoded, _ := json.Marshal(rpcFailure(rpcNull, rpcParseError, "parse error: %v", err))
+		return encoded
+	}
+	if len(batch) == 0 {
+		encoded, _ := json.Marshal(rpcFailure(rpcNull, rpcInvalidRequest, "invalid request: empty batch"))
+		return encoded
+	}
+	var responses []*rpcResponse
+	for _, request := range batch {
+		if response := self.call(ctx, request); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	encoded, _ := json.Marshal(responses)
+	return encoded
+}
+
+// call runs one request, returning nil for notifications.
+func (self *Daemon) call(ctx context.Context, data []byte) *rpcResponse {
+	if !json.Valid(data) {
+		return rpcFailure(rpcNull, rpcParseError, "parse error: invalid JSON")
+	}
+	var request rpcRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.JSONRPC != "2.0" || request.Method == "" {
+		id := request.ID
+		if id == nil {
+			id = rpcNull
+		}
+		return rpcFailure(id, rpcInvalidRequest, "invalid request")
+	}
+	var response *rpcResponse
+	switch request.Method {
+	case "run":
+		var params runParams
+		if err := json.Unmarshal(request.Params, &params); err != nil || params.Macro == "" {
+			response = rpcFailure(request.ID, rpcInvalidParams, "invalid params: run takes a macro")
+		} else if err := self.run(ctx, params.Macro, params.Touch); err != nil {
+			response = rpcFailure(request.ID, DaemonMacroFailed, "%v", err)
+		} else {
+			response = &rpcResponse{JSONRPC: "2.0", ID: request.ID, Result: rpcNull}
+		}
+	default:
+		response = rpcFailure(request.ID, rpcMethodNotFound, "method not found: %v", request.Method)
+	}
+	if request.ID == nil {
+		return nil
+	}
+	return response
+}
+
+func rpcFailure(id json.RawMessage, code int, format string, args ...interface{}) *rpcResponse {
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &DaemonError{code, fmt.Sprintf(format, args...)}}
+}
+
+// run runs a macro on the keyboard and the mouse, or the touchpad.
+func (self *Daemon) run(ctx context.Context, source string, touch bool) error {
+	pointer := self.mouse
+	if touch {
+		if self.touchpad == nil {
+			return fmt.Errorf("[error] the daemon has no touchpad, it needs a screen size")
+		}
+		pointer = self.touchpad
+	}
+	macro, err := ParseMacro(source)
+	if err != nil {
+		return err
+	}
+	if err := macro.Validate(); err != nil {
+		return err
+	}
+	// NOTE: Clients take turns, so the events of their macros never
+	// interleave on the shared devices.
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return macro.Run(ctx, self.keyboard, pointer)
+}
+
+// DaemonClient sends macros to a daemon.
+type DaemonClient struct {
+	conn    net.Conn
+	replies *bufio.Scanner
+	id      int64
+}
+
+func DialDaemon(path string) (*DaemonClient, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("[error] failed to connect to the daemon: %v", err)
+	}
+	return newDaemonClient(conn), nil
+}
+
+func newDaemonClient(conn net.Conn) *DaemonClient {
+	replies := bufio.NewScanner(conn)
+	replies.Buffer(nil, daemonMaxRequest)
+	return &DaemonClient{conn: conn, replies: replies}
+}
+
+// Run sends a macro and waits for the daemon to have run it; failures are
+// returned as a *DaemonError.
+func (self *DaemonClient) Run(macro string) error {
+	return self.call("run", runParams{Macro: macro})
+}
+
+// RunTouch runs a macro with its pointer commands on the touchpad.
+func (self *DaemonClient) RunTouch(macro string) error {
+	return self.call("run", runParams{Macro: macro, Touch: true})
+}
+
+func (self *DaemonClient) call(method string, params interface{}) error {
+	self.id++
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	request, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(strconv.FormatInt(self.id, 10)),
+		Method:  method,
+		Params:  encodedParams,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := self.conn.Write(append(request, '\n')); err != nil {
+		return err
+	}
+	if !self.replies.Scan() {
+		if err := self.replies.Err(); err != nil {
+			return err
+		}
+		return errors.New("[error] the daemon closed the connection")
+	}
+	var response rpcResponse
+	if err := json.Unmarshal(self.replies.Bytes(), &response); err != nil {
+		return fmt.Errorf("[error] invalid daemon response: %v", err)
+	}
+	// NOTE: Errors answered before the request was read, such as a refused
+	// client, carry a null id.
+	if response.Error != nil {
+		return response.Error
+	}
+	if string(response.ID) != strconv.FormatInt(self.id, 10) {
+		return fmt.Errorf("[error] daemon response for request %s, expected %d", response.ID, self.id)
+	}
+	return nil
+}
+
+func (self *DaemonClient) Close() error {
+	return self.conn.Close()
+}
@@ -0,0 +1,190 @@
+package uinput
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dryRunDaemon returns a daemon whose devices trace their frames to trace.
+func dryRunDaemon(t *testing.T, trace *bytes.Buffer) *Daemon {
+	t.Helper()
+	keyboard, err := Keyboard.DryRun("daemon keyboard", Trace{Writer: trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mouse, err := Mouse.DryRun("daemon mouse", Trace{Writer: trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{keyboard: keyboard, mouse: mouse}
+	t.Cleanup(daemon.disconnect)
+	return daemon
+}
+
+func TestDaemonProtocol(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		request  string
+		response string
+		trace    string
+	}{
+		{
+			name:     "run",
+			request:  `{"jsonrpc": "2.0", "id": 1, "method": "run", "params": {"macro": "key a"}}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":null}`,
+			trace:    "KEY_A pressed",
+		},
+		{
+			name:    "notification",
+			request: `{"jsonrpc": "2.0", "method": "run", "params": {"macro": "key a"}}`,
+			trace:   "KEY_A pressed",
+		},
+		{
+			name:     "string id",
+			request:  `{"jsonrpc": "2.0", "id": "a", "method": "run", "params": {"macro": "key a"}}`,
+			response: `{"jsonrpc":"2.0","id":"a","result":null}`,
+		},
+		{
+			name:     "parse error",
+			request:  `{"jsonrpc": "2.0", "id": 1`,
+			response: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error: invalid JSON"}}`,
+		},
+		{
+			name:     "missing version",
+			request:  `{"id": 2, "method": "run"}`,
+			response: `{"jsonrpc":"2.0","id":2,"error":{"code":-32600,"message":"invalid request"}}`,
+		},
+		{
+			name:     "not an object",
+			request:  `5`,
+			response: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`,
+		},
+		{
+			name:     "unknown method",
+			request:  `{"jsonrpc": "2.0", "id": 3, "method": "type"}`,
+			response: `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"method not found: type"}}`,
+		},
+		{
+			name:     "missing macro",
+			request:  `{"jsonrpc": "2.0", "id": 4, "method": "run", "params": {}}`,
+			response: `{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"invalid params: run takes a macro"}}`,
+		},
+		{
+			name:     "touch without a touchpad",
+			request:  `{"jsonrpc": "2.0", "id": 5, "method": "run", "params": {"macro": "click", "touch": true}}`,
+			response: `{"jsonrpc":"2.0","id":5,"error":{"code":-32000,"message":"[error] the daemon has no touchpad, it needs a screen size"}}`,
+		},
+		{
+			name:    "batch",
+			request: `[{"jsonrpc": "2.0", "id": 1, "method": "run", "params": {"macro": "key b"}}, {"jsonrpc": "2.0", "method": "run", "params": {"macro": "key c"}}, {"jsonrpc": "2.0", "id": 2, "method": "nope"}]`,
+			response: `[{"jsonrpc":"2.0","id":1,"result":null},` +
+				`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: nope"}}]`,
+			trace: "KEY_C pressed",
+		},
+		{
+			name:    "batch of notifications",
+			request: `[{"jsonrpc": "2.0", "method": "run", "params": {"macro": "key a"}}]`,
+		},
+		{
+			name:     "empty batch",
+			request:  `[]`,
+			response: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request: empty batch"}}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var trace bytes.Buffer
+			daemon := dryRunDaemon(t, &trace)
+			response := daemon.handle(context.Background(), []byte(test.request))
+			if string(response) != test.response {
+				t.Errorf("answered %s, want %s", response, test.response)
+			}
+			if !strings.Contains(trace.String(), test.trace) {
+				t.Errorf("traced %q, want %q", trace.String(), test.trace)
+			}
+		})
+	}
+}
+
+func TestDaemonMacroFailure(t *testing.T) {
+	daemon := dryRunDaemon(t, &bytes.Buffer{})
+	response := daemon.handle(context.Background(), []byte(`{"jsonrpc": "2.0", "id": 1, "method": "run", "params": {"macro": "dance"}}`))
+	if !bytes.Contains(response, []byte(`"code":-32000`)) {
+		t.Errorf("answered %s, want a macro failure", response)
+	}
+}
+
+func TestDaemonClient(t *testing.T) {
+	var trace bytes.Buffer
+	daemon := dryRunDaemon(t, &trace)
+	server, conn := net.Pipe()
+	go func() {
+		defer server.Close()
+		requests := bufio.NewScanner(server)
+		for requests.Scan() {
+			if response := daemon.handle(context.Background(), requests.Bytes()); response != nil {
+				server.Write(append(response, '\n'))
+			}
+		}
+	}()
+	client := newDaemonClient(conn)
+	defer client.Close()
+	if err := client.Run("key ctrl+l; type \"hi\""); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(trace.String(), "KEY_L pressed") || !strings.Contains(trace.String(), "KEY_H pressed") {
+		t.Errorf("traced %q", trace.String())
+	}
+	var daemonErr *DaemonError
+	if err := client.RunTouch("click"); !errors.As(err, &daemonErr) || daemonErr.Code != DaemonMacroFailed {
+		t.Errorf("got %v, want a macro failure", err)
+	}
+	if err := client.Run("key a"); err != nil {
+		t.Errorf("client out of step after a failure: %v", err)
+	}
+}
+
+func TestDaemonClientHangUpStopsMacro(t *testing.T) {
+	var trace bytes.Buffer
+	daemon := dryRunDaemon(t, &trace)
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: filepath.Join(t.TempDir(), "daemon.sock"), Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	client, err := net.Dial("unix", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := listener.AcceptUnix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		daemon.serveClient(context.Background(), conn)
+	}()
+	responses := bufio.NewScanner(client)
+	client.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "method": "run", "params": {"macro": "key b"}}` + "\n"))
+	if !responses.Scan() {
+		t.Fatalf("no answer: %v", responses.Err())
+	}
+	client.Write([]byte(`{"jsonrpc": "2.0", "id": 2, "method": "run", "params": {"macro": "wait 1m; key a"}}` + "\n"))
+	time.Sleep(20 * time.Millisecond)
+	client.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the macro kept running after the client hung up")
+	}
+	if !strings.Contains(trace.String(), "KEY_B pressed") || strings.Contains(trace.String(), "KEY_A") {
+		t.Errorf("traced %q, want key b only", trace.String())
+	}
+}
@@ -0,0 +1,287 @@
+package uinput
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"unsafe"
+)
+
+// DeviceDescription is everything needed to recreate an input device through
+// uinput: its identity, the event codes it reports and the range of each
+// absolute axis. It is what sessions record ahead of the event stream.
+type DeviceDescription struct {
+	Name         string
+	Phys         string
+	Id           deviceId
+	Properties   []DeviceProperty
+	Capabilities map[EventType][]EventCode
+	Abs          map[EventCode]AbsInfo
+	// Force feedback is only recreated when the effect count is known,
+	// uinput refuses EV_FF devices without one.
+	EffectsMax uint32
+}
+
+// jsonDescription is the JSON form of a DeviceDescription, naming event types,
+// codes and properties as input.h does.
+type jsonDescription struct {
+	Name         string              `json:"name"`
+	Phys         string              `json:"phys,omitempty"`
+	Id           deviceId            `json:"id"`
+	Properties   []string            `json:"properties,omitempty"`
+	Capabilities map[string][]string `json:"capabilities"`
+	Abs          map[string]AbsInfo  `json:"abs,omitempty"`
+	EffectsMax   uint32              `json:"ff_effects_max,omitempty"`
+}
+
+func (self DeviceDescription) MarshalJSON() ([]byte, error) {
+	description := jsonDescription{
+		Name:         self.Name,
+		Phys:         self.Phys,
+		Id:           self.Id,
+		Capabilities: make(map[string][]string),
+		Abs:          make(map[string]AbsInfo),
+		EffectsMax:   self.EffectsMax,
+	}
+	for _, property := range self.Properties {
+		description.Properties = append(description.Properties, property.String())
+	}
+	for eventType, codes := range self.Capabilities {
+		names := make([]string, 0, len(codes))
+		for _, code := range codes {
+			names = append(names, CodeName(eventType, code))
+		}
+		description.Capabilities[eventType.String()] = names
+	}
+	for axis, info := range self.Abs {
+		description.Abs[CodeName(EV_ABS, axis)] = info
+	}
+	return json.Marshal(description)
+}
+
+func (self *DeviceDescription) UnmarshalJSON(data []byte) error {
+	var description jsonDescription
+	if err := json.Unmarshal(data, &description); err != nil {
+		return err
+	}
+	self.Name, self.Phys, self.Id = description.Name, description.Phys, description.Id
+	self.EffectsMax = description.EffectsMax
+	self.Properties = nil
+	for _, name := range description.Properties {
+		property, err := ParseProperty(name)
+		if err != nil {
+			return err
+		}
+		self.Properties = append(self.Properties, property)
+	}
+	self.Capabilities = make(map[EventType][]EventCode)
+	for typeName, names := range description.Capabilities {
+		eventType, err := ParseEventType(typeName)
+		if err != nil {
+			return err
+		}
+		codes := make([]EventCode, 0, len(names))
+		for _, name := range names {
+			code, err := ParseEventCode(eventType, name)
+			if err != nil {
+				return err
+			}
+			codes = append(codes, code)
+		}
+		self.Capabilities[eventType] = codes
+	}
+	self.Abs = make(map[EventCode]AbsInfo)
+	for name, info := range description.Abs {
+		axis, err := ParseEventCode(EV_ABS, name)
+		if err != nil {
+			return err
+		}
+		self.Abs[axis] = info
+	}
+	return nil
+}
+
+// bitGroups maps the bitfield groups of /proc/bus/input/devices to the event
+// type whose codes they hold.
+var bitGroups = map[string]EventType{
+	keyGroup: EV_KEY,
+	"REL":    EV_REL,
+	absGroup: EV_ABS,
+	"MSC":    EV_MSC,
+	"SW":     EV_SW,
+	"LED":    EV_LED,
+	"SND":    EV_SND,
+	"FF":     EV_FF,
+}
+
+// description converts the bitfields parsed from procfs into a description,
+// without axis ranges which procfs does not expose.
+func (self *deviceInfo) description() DeviceDescription {
+	description := DeviceDescription{
+		Name:         self.name,
+		Phys:         self.phys,
+		Id:           self.deviceId,
+		Capabilities: make(map[EventType][]EventCode),
+		Abs:          make(map[EventCode]AbsInfo),
+	}
+	for _, eventType := range []EventType{EV_KEY, EV_REL, EV_ABS, EV_MSC, EV_SW, EV_LED, EV_SND, EV_REP, EV_FF} {
+		if self.hasBit(evGroup, eventType.Code()) {
+			description.Capabilities[eventType] = nil
+		}
+	}
+	for group, eventType := range bitGroups {
+		if bits, ok := self.bits[group]; ok {
+			description.Capabilities[eventType] = setBits(bits)
+		}
+	}
+	for _, property := range setBits(self.bits["PROP"]) {
+		description.Properties = append(description.Properties, DeviceProperty(property))
+	}
+	return description
+}
+
+func setBits(bits *big.Int) (codes []EventCode) {
+	if bits == nil {
+		return nil
+	}
+	for n := 0; n < bits.BitLen(); n++ {
+		if bits.Bit(n) != 0 {
+			codes = append(codes, EventCode(n))
+		}
+	}
+	return codes
+}
+
+// DescribeDevice describes the input event device at path, e.g.
+// "/dev/input/event3", from /proc/bus/input/devices and the axis ranges the
+// device reports.
+func DescribeDevice(path string) (DeviceDescription, error) {
+	infos, err := readDevices("")
+	if err != nil {
+		return DeviceDescription{}, err
+	}
+	for _, info := range infos {
+		if info.path != path {
+			continue
+		}
+		description := info.description()
+		if axes := description.Capabilities[EV_ABS]; len(axes) > 0 {
+			reader, err := OpenEventReader(path)
+			if err != nil {
+				return description, err
+			}
+			defer reader.Close()
+			for _, axis := range axes {
+				if description.Abs[axis], err = reader.AbsInfo(axis); err != nil {
+					return description, err
+				}
+			}
+		}
+		return description, nil
+	}
+	return DeviceDescription{}, fmt.Errorf("[error] no input device found at %v", path)
+}
+
+// Connect creates a virtual device matching the description.
+func (self DeviceDescription) Connect() (VirtualDevice, error) {
+	device, err := Custom.Create(self.Name)
+	if err != nil {
+		return nil, err
+	}
+	dev := device.(*Device)
+	dev.Id = self.Id
+	dev.Phys = self.Phys
+	dev.EffectsMax = self.EffectsMax
+	dev.Properties = self.Properties
+	dev.Capabilities = make(map[EventType][]EventCode)
+	for eventType, codes := range self.Capabilities {
+		if eventType == EV_FF && self.EffectsMax == 0 {
+			continue
+		}
+		dev.Capabilities[eventType] = codes
+	}
+	for axis, info := range self.Abs {
+		if int(axis) >= size {
+			continue
+		}
+		dev.AbsMin[axis], dev.AbsMax[axis] = info.Minimum, info.Maximum
+		dev.Fuzz[axis], dev.Flat[axis] = info.Fuzz, info.Flat
+		dev.Resolution[axis] = info.Resolution
+	}
+	return dev.Connect()
+}
+
+// capabilityBits are the ioctls enabling individual codes of an event type;
+// types missing here (EV_SYN, EV_REP) only need their event bit set.
+var capabilityBits = map[EventType]ioctlType{
+	EV_KEY: KeyBit,
+	EV_REL: RelativeBit,
+	EV_ABS: AbsoluteBit,
+	EV_MSC: MiscBit,
+	EV_SW:  SwitchBit,
+	EV_LED: LEDBit,
+	EV_SND: SoundBit,
+	EV_FF:  ForceFeedbackBit,
+}
+
+// RegisterCapabilities registers every event type, code and property listed
+// on the device, in a stable order.
+func (self *Device) RegisterCapabilities() error {
+	for _, eventType := range sortedEventTypes(self.Capabilities) {
+		if eventType == EV_SYN {
+			continue
+		}
+		if err := self.newEventSource(eventType); err != nil {
+			self.FD.Close()
+			return err
+		}
+		bit, ok := capabilityBits[eventType]
+		if !ok {
+			continue
+		}
+		for _, code := range self.Capabilities[eventType] {
+			if err := ioctl(self.FD, bit.Code(), uintptr(code)); err != nil {
+				self.FD.Close()
+				return fmt.Errorf("[error] failed to register event %d of type %d: %v", code, eventType.Code(), err)
+			}
+		}
+	}
+	for _, property := range self.Properties {
+		if err := ioctl(self.FD, PropertyBit.Code(), uintptr(property)); err != nil {
+			self.FD.Close()
+			return fmt.Errorf("[error] failed to register property %d: %v", property, err)
+		}
+	}
+	return nil
+}
+
+// uinputAbsSetup mirrors struct uinput_abs_setup from uinput.h.
+type uinputAbsSetup struct {
+	Code uint16
+	_    uint16
+	Info AbsInfo
+}
+
+// setupResolutions applies axis resolutions, which the uinput_user_dev
+// structure has no room for, through UI_ABS_SETUP.
+func (self *Device) setupResolutions() error {
+	for axis, resolution := range self.Resolution {
+		if resolution == 0 {
+			continue
+		}
+		setup := uinputAbsSetup{
+			Code: uint16(axis),
+			Info: AbsInfo{
+				Minimum:    self.AbsMin[axis],
+				Maximum:    self.AbsMax[axis],
+				Fuzz:       self.Fuzz[axis],
+				Flat:       self.Flat[axis],
+				Resolution: resolution,
+			},
+		}
+		if err := ioctl(self.FD, AbsoluteSetup.Code(), uintptr(unsafe.Pointer(&setup))); err != nil {
+			return fmt.Errorf("[error] failed to set resolution of axis %d: %v", axis, err)
+		}
+	}
+	return nil
+}
@@ -3,12 +3,17 @@ package uinput
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 )
 
+// ErrDisconnected is returned when writing to a device after Disconnect.
+var ErrDisconnected = errors.New("[error] device is disconnected")
+
 type DeviceType int
 
 const (
@@ -17,6 +22,8 @@ const (
 	Tablet // Uses absolute position typically
 	Touchpad
 	Gamepad
+	Switch // Lid, tablet mode, headphone jack and similar EV_SW sources
+	Custom // Registers exactly the Capabilities listed on the device
 	// TODO: It should be very easy to leverage uinput for sensor input or
 	//       custom hardware input prototyping
 )
@@ -84,13 +91,26 @@ type ScreenSize struct {
 	Height int32
 }
 
+func (self ScreenSize) position() Point {
+	return Point{X: self.Width, Y: self.Height}
+}
+
 // TODO: Why not string and just ability to output as byte or marshal from
 // bytes?
 //
 //	Yeah, I definitely want it to be a string and we just have the ability
 //	to take in or output bytes as needed
+//
+// Device is a handle on a virtual device and is only used through a pointer,
+// as returned by Create. Once connected it is safe for concurrent use: every
+// method reporting input writes its events and the closing SYN_REPORT as one
+// frame, and frames from different goroutines are never interleaved. Methods
+// made of several frames, such as Tap, TypeText or Drag, are not atomic, so
+// frames of other goroutines may land between them. The exported fields
+// configure the device and must not be changed once it is connected.
 type Device struct {
 	Name       [80]byte
+	Phys       string
 	FD         *os.File
 	screenSize ScreenSize
 	Id         deviceId
@@ -98,8 +118,43 @@ type Device struct {
 	Type       DeviceType
 	Events     []InputEvent
 	EffectsMax uint32
+	AbsMin     [size]int32
+	AbsMax     [size]int32
 	Fuzz       [size]int32
 	Flat       [size]int32
+	Resolution [size]int32
+	// Event codes and properties registered on Custom devices
+	Capabilities map[EventType][]EventCode
+	Properties   []DeviceProperty
+	// Current state of each declared switch, keyed by SW_ event code
+	Switches map[EventCode]bool
+	// LED and bell state written back by the kernel, only set on keyboards
+	Keyboard *VirtualKeyboard
+	// Key repeat rate, registered with the kernel when Autorepeat.Kernel is set
+	Autorepeat Autorepeat
+	// Report MSC_SCAN with the HID usage ahead of each key event
+	ScanCodes bool
+	// Pointer timings, zero values fall back to DefaultClickTiming
+	ClickTiming ClickTiming
+	// Stamps the events written and times the helpers holding keys and
+	// buttons, SystemClock when nil
+	Clock Clock
+
+	// What to do with events that would not change the device state
+	RedundantEvents RedundantEvents
+	// Safeguards against flooding the desktop, off when zero
+	Limits Limits
+	// Shows the frames written, or writes them nowhere else with DryRun
+	Trace Trace
+
+	// frames serializes the frames written to FD, taken before mutex and held
+	// while a frame waits on the limiter
+	frames sync.Mutex
+	// mutex guards FD on Disconnect, along with the state the frames leave
+	// the device in and the limiter
+	mutex   sync.Mutex
+	state   DeviceState
+	limiter limiter
 }
 
 type DeviceName string
@@ -110,18 +165,24 @@ func (name DeviceName) Bytes() [80]byte {
 	return truncatedName
 }
 
+// New is an alias of Create.
+func (devType DeviceType) New(name string) (VirtualDevice, error) {
+	return devType.Create(name)
+}
+
 func (devType DeviceType) Create(name string) (VirtualDevice, error) {
 	var truncatedName [maxDeviceNameLength]byte
 	copy(truncatedName[:], []byte(name))
-	device := Device{
+	device := &Device{
 		Name:       truncatedName,
 		Type:       devType,
 		EffectsMax: 0,
+		Switches:   make(map[EventCode]bool),
 	}
 	var err error
 	device.FD, err = OpenFileDescriptor(uinputPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	// NOTE: This sleep allows time for userspace to find the new device and
 	// initialize it for our use, then we can continue configuring the device.
@@ -129,7 +190,16 @@ func (devType DeviceType) Create(name string) (VirtualDevice, error) {
 	return device, err
 }
 
-func (dev Device) ScreenSize(width, height int32) VirtualDevice {
+func (dev *Device) clock() Clock {
+	if dev.Clock == nil {
+		return SystemClock
+	}
+	return dev.Clock
+}
+
+// ScreenSize sets the screen size absolute axes default to; it must be called
+// before Connect.
+func (dev *Device) ScreenSize(width, height int32) VirtualDevice {
 	// TODO: Validate the values
 	dev.screenSize = ScreenSize{
 		Width:  width,
@@ -138,71 +208,193 @@ func (dev Device) ScreenSize(width, height int32) VirtualDevice {
 	return dev
 }
 
-func (dev Device) Connect() (VirtualDevice, error) {
+func (dev *Device) Connect() (VirtualDevice, error) {
 	switch dev.Type {
 	case Keyboard:
-		dev.RegisterDefaultKeymap()
-		dev.Id = NewDeviceId(Keyboard)
+		if err := dev.RegisterDefaultKeymap(); err != nil {
+			return nil, err
+		}
+		if err := dev.RegisterFeedback(); err != nil {
+			return nil, err
+		}
+		if dev.Autorepeat.Kernel {
+			if err := dev.RegisterAutorepeat(); err != nil {
+				return nil, err
+			}
+		}
+		if dev.ScanCodes {
+			if err := dev.RegisterScanCodes(); err != nil {
+				return nil, err
+			}
+		}
+		dev.Keyboard = newVirtualKeyboard()
+		dev.Id = dev.identity(Keyboard)
 	case Mouse:
-		dev.RegisterTwoPointerButtons()
-		dev.RegisterAxis(Relative)
-		dev.Id = NewDeviceId(Mouse)
+		if err := dev.RegisterTwoPointerButtons(); err != nil {
+			return nil, err
+		}
+		if err := dev.RegisterAxis(Relative); err != nil {
+			return nil, err
+		}
+		if err := dev.RegisterWheels(); err != nil {
+			return nil, err
+		}
+		dev.Id = dev.identity(Mouse)
 	case Touchpad:
-		dev.RegisterTwoPointerButtons()
-		dev.RegisterAxis(Absolute)
-		dev.Id = NewDeviceId(Touchpad)
-		//Min: min.Slice(),
-		//Max: max.Slice(),
+		if err := dev.RegisterTwoPointerButtons(); err != nil {
+			return nil, err
+		}
+		if err := dev.RegisterAxis(Absolute); err != nil {
+			return nil, err
+		}
+		dev.Id = dev.identity(Touchpad)
+		if dev.AbsMax[XAxis.Code()] == 0 && dev.AbsMax[YAxis.Code()] == 0 {
+			dev.AbsMax = dev.screenSize.position().Slice()
+		}
+	case Switch:
+		if err := dev.RegisterSwitches(); err != nil {
+			return nil, err
+		}
+		dev.Id = dev.identity(Switch)
+	case Custom:
+		if err := dev.RegisterCapabilities(); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("[error] invalid device could not connect")
 	}
-	if err := ioctl(dev.FD, EventBit.Code(), uintptr(0)); err != nil {
+	if dev.Phys != "" {
+		if err := dev.setPhys(); err != nil {
+			return nil, err
+		}
+	}
+	if err := dev.writeUserDevice(); err != nil {
+		dev.FD.Close()
+		return nil, err
+	}
+	if err := dev.setupResolutions(); err != nil {
 		dev.FD.Close()
-		return nil, fmt.Errorf("[error] invalid file handle returned from ioctl: %v", err)
+		return nil, err
+	}
+	if err := ioctl(dev.FD, CreateDevice.Code(), uintptr(0)); err != nil {
+		dev.FD.Close()
+		return nil, fmt.Errorf("[error] failed to create new device: %v", err)
+	}
+	if dev.Keyboard != nil || dev.EffectsMax > 0 {
+		go dev.readFeedback(dev.FD)
+	}
+	if dev.Autorepeat.Kernel {
+		if err := dev.reportAutorepeat(); err != nil {
+			dev.destroy()
+			return nil, err
+		}
+	}
+	if dev.Type == Switch {
+		// NOTE: The kernel starts every switch in the off state, so the
+		// declared initial state is reported as soon as the device exists.
+		if err := dev.reportSwitches(); err != nil {
+			dev.destroy()
+			return nil, err
+		}
 	}
 	return dev, nil
 }
 
-func (dev Device) Disconnect() (VirtualDevice, error) {
-	if err := ioctl(dev.FD, RemoveDevice.Code(), uintptr(0)); err != nil {
-		return nil, fmt.Errorf("[error] failed to remove virtual device: %v", err)
+// destroy removes a device Connect created but failed to set up.
+func (dev *Device) destroy() {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	ioctl(dev.FD, RemoveDevice.Code(), uintptr(0))
+	dev.FD.Close()
+	dev.FD = nil
+}
+
+// uinputUserDev mirrors struct uinput_user_dev from uinput.h, the setup
+// structure written to the uinput file descriptor before UI_DEV_CREATE.
+type uinputUserDev struct {
+	Name       [maxDeviceNameLength]byte
+	Id         deviceId
+	EffectsMax uint32
+	AbsMax     [size]int32
+	AbsMin     [size]int32
+	AbsFuzz    [size]int32
+	AbsFlat    [size]int32
+}
+
+func (dev *Device) writeUserDevice() error {
+	deviceBuffer := new(bytes.Buffer)
+	if err := binary.Write(deviceBuffer, binary.LittleEndian, uinputUserDev{
+		Name:       dev.Name,
+		Id:         dev.Id,
+		EffectsMax: dev.EffectsMax,
+		AbsMax:     dev.AbsMax,
+		AbsMin:     dev.AbsMin,
+		AbsFuzz:    dev.Fuzz,
+		AbsFlat:    dev.Flat,
+	}); err != nil {
+		return fmt.Errorf("[error] failed to write user device buffer: %v", err)
+	}
+	if _, err := dev.FD.Write(deviceBuffer.Bytes()); err != nil {
+		return fmt.Errorf("[error] failed to write uidev struct to device file: %v", err)
+	}
+	return nil
+}
+
+// Disconnect releases the keys, buttons and touches still held then removes
+// the virtual device, waiting for a frame being written by another goroutine
+// unless it waits on the Limits; later writes fail with ErrDisconnected.
+func (dev *Device) Disconnect() (VirtualDevice, error) {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	if dev.FD == nil {
+		return nil, ErrDisconnected
+	}
+	// NOTE: The device is removed even when the release fails, a stuck key
+	// on a device that still exists would be worse.
+	dev.releaseAllLocked()
+	dev.stopHolds()
+	if !dev.Trace.DryRun {
+		if err := ioctl(dev.FD, RemoveDevice.Code(), uintptr(0)); err != nil {
+			return nil, fmt.Errorf("[error] failed to remove virtual device: %v", err)
+		}
 	}
 	if err := dev.FD.Close(); err != nil {
 		return nil, fmt.Errorf("[error] failed to close device fd: %v", err)
 	}
-	dev.FD = &os.File{}
+	dev.FD = nil
+	if dev.Trace.DryRun && dev.Keyboard != nil {
+		// NOTE: Dry-run keyboards have no feedback reader to close these.
+		dev.Keyboard.closeFeedback()
+	}
 	return dev, nil
 }
 
+// uinputOpenFlags open uinput for writing events and reading the LED, bell and
+// force feedback requests back, without blocking the feedback reader.
+const uinputOpenFlags = syscall.O_RDWR | syscall.O_NONBLOCK
+
 func OpenFileDescriptor(uiPath string) (deviceFD *os.File, err error) {
-	if deviceFD, err := os.OpenFile(uiPath, syscall.O_WRONLY|syscall.O_NONBLOCK, 0660); err != nil {
+	if deviceFD, err := os.OpenFile(uiPath, uinputOpenFlags, 0660); err != nil {
+		if uiPath == uinputPath {
+			// NOTE: The first advice is the most likely fix, Diagnose has the rest.
+			if advice := Diagnose().Advice; len(advice) > 0 {
+				return nil, fmt.Errorf("[error] could not open device file descriptor: %v; %v (see uinput.Diagnose)", err, advice[0])
+			}
+		}
 		return nil, fmt.Errorf("[error] could not open device file descriptor: %v", err)
 	} else {
-		deviceBuffer := new(bytes.Buffer)
-		if err = binary.Write(deviceBuffer, binary.LittleEndian, deviceFD); err != nil {
-			deviceFD.Close()
-			return nil, fmt.Errorf("[error] failed to write user device buffer: %v", err)
-		}
-		if _, err = deviceFD.Write(deviceBuffer.Bytes()); err != nil {
-			deviceFD.Close()
-			return nil, fmt.Errorf("[error] failed to write uidev struct to device file: %v", err)
-		}
-		if err = ioctl(deviceFD, NewDevice.Code(), uintptr(0)); err != nil {
-			deviceFD.Close()
-			return nil, fmt.Errorf("[error] failed to create new device: %v", err)
-		}
 		return deviceFD, nil
 	}
 }
 
-func (self Device) newEventSource(eventType EventType) error {
-	if err := ioctl(self.FD, Event.Code(), uintptr(eventType)); err != nil {
+func (self *Device) newEventSource(eventType EventType) error {
+	if err := ioctl(self.FD, Event.Code(), uintptr(eventType.Code())); err != nil {
 		return fmt.Errorf("[error] invalid file handle returned from ioctl: %v", err)
 	}
 	return nil
 }
 
-func (self Device) RegisterKey(key EventCode) error {
+func (self *Device) RegisterKey(key EventCode) error {
 	if err := ioctl(self.FD, RegisterKey.Code(), uintptr(key)); err != nil {
 		return fmt.Errorf("[error] failed to register key %d: %v", key, err)
 	}
@@ -211,14 +403,15 @@ func (self Device) RegisterKey(key EventCode) error {
 
 // TODO: This belongs in keyboard, and should pass in keymap type, which would
 // hold like 108, 128, allkeys, etc
-func (self Device) RegisterDefaultKeymap() error {
+func (self *Device) RegisterDefaultKeymap() error {
 	if err := self.newEventSource(EV_KEY); err != nil {
 		self.FD.Close()
-		panic(err)
+		return err
 	}
 	for _, keycode := range DefaultKeymap() {
 		if err := self.RegisterKey(keycode); err != nil {
-			panic(err)
+			self.FD.Close()
+			return err
 		}
 	}
 	return nil
@@ -228,23 +421,23 @@ func (self Device) RegisterDefaultKeymap() error {
 // until we can add types based on common types. Eventually should likely have
 // either instead of MouseType have 3ButtonMouse, 2BUttonMouse, or Mouse then
 // subtypes
-func (self Device) RegisterTwoPointerButtons() error {
+func (self *Device) RegisterTwoPointerButtons() error {
 	if err := self.newEventSource(EV_KEY); err != nil {
 		self.FD.Close()
-		panic(err)
+		return err
 	}
-	if err := ioctl(self.FD, RelativeBit.Code(), uintptr(LeftButton.EventCode())); err != nil {
+	if err := ioctl(self.FD, KeyBit.Code(), uintptr(LeftButton.EventCode())); err != nil {
 		self.FD.Close()
 		return fmt.Errorf("[error] failed to register left click event: %v", err)
 	}
-	if err := ioctl(self.FD, RelativeBit.Code(), uintptr(RightButton.EventCode())); err != nil {
+	if err := ioctl(self.FD, KeyBit.Code(), uintptr(RightButton.EventCode())); err != nil {
 		self.FD.Close()
 		return fmt.Errorf("[error] failed to register right click event: %v", err)
 	}
 	return nil
 }
 
-func (self Device) RegisterAxis(axisType MoveType) error {
+func (self *Device) RegisterAxis(axisType MoveType) error {
 	switch axisType {
 	case Relative:
 		if err := self.newEventSource(EV_REL); err != nil {
@@ -1,7 +1,15 @@
 package uinput
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	usb "github.com/multiverse-os/uinput/usb-id"
 )
 
 type deviceId struct{ busType, vendor, product, version uint16 }
@@ -29,11 +37,69 @@ func NewDeviceId(deviceType DeviceType) deviceId {
 			product: 0x0817,
 			version: 1,
 		}
+	case Switch:
+		return deviceId{
+			busType: USB.Code(),
+			vendor:  0x4711,
+			product: 0x0818,
+			version: 1,
+		}
 	default:
 		return deviceId{}
 	}
 }
 
+// jsonDeviceId is the JSON form of a deviceId, with each value in hex as it is
+// printed by lsusb and /proc/bus/input/devices.
+type jsonDeviceId struct {
+	BusType string `json:"bus"`
+	Vendor  string `json:"vendor"`
+	Product string `json:"product"`
+	Version string `json:"version"`
+}
+
+// String formats the id as bus:vendor:product:version in hex, as evemu and
+// udev do.
+func (self deviceId) String() string {
+	return fmt.Sprintf("%04x:%04x:%04x:%04x", self.busType, self.vendor, self.product, self.version)
+}
+
+func (self deviceId) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDeviceId{
+		BusType: fmt.Sprintf("%04x", self.busType),
+		Vendor:  fmt.Sprintf("%04x", self.vendor),
+		Product: fmt.Sprintf("%04x", self.product),
+		Version: fmt.Sprintf("%04x", self.version),
+	})
+}
+
+func (self *deviceId) UnmarshalJSON(data []byte) error {
+	var id jsonDeviceId
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	for _, field := range []struct {
+		value  string
+		target *uint16
+	}{
+		{id.BusType, &self.busType},
+		{id.Vendor, &self.vendor},
+		{id.Product, &self.product},
+		{id.Version, &self.version},
+	} {
+		if field.value == "" {
+			*field.target = 0
+			continue
+		}
+		value, err := strconv.ParseUint(field.value, 16, 16)
+		if err != nil {
+			return fmt.Errorf("[error] invalid device id value %q: %v", field.value, err)
+		}
+		*field.target = uint16(value)
+	}
+	return nil
+}
+
 // TODO: Same with the BusType, we should make some simple vendor and product
 //       so its super easy to convert output from `lspci -nn` instead of
 //       forcing the user to need to know how to do the hex for the value
@@ -50,8 +116,53 @@ func ProductId(id uint16) string {
 	return fmt.Sprintf("%v", id)
 }
 
-// TODO: Use usb-ids and some additional code to abstract pulling real
-// randomized vendor ids and product ids by a type (i.e. keyboard, mouse, etc)
+// identityKeywords pick the products of the usb ids plausible for each device
+// type by name, leaving out combined devices.
+var identityKeywords = map[DeviceType]struct{ include, exclude []string }{
+	Keyboard: {[]string{"keyboard"}, []string{"mouse", "hub", "adapter", "switch"}},
+	Mouse:    {[]string{"mouse"}, []string{"keyboard", "adapter", "pad"}},
+	Tablet:   {[]string{"tablet", "digitizer"}, []string{"adapter"}},
+	Touchpad: {[]string{"touchpad", "trackpad"}, []string{"keyboard"}},
+	Gamepad:  {[]string{"gamepad", "game pad", "joypad", "joystick"}, []string{"adapter", "hub"}},
+}
+
+var identityRandom = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// RandomIdentity picks the vendor and product of a real USB device of the
+// given type from the usb ids, so the virtual device passes for a common
+// one. Device types with no such products, switches and custom devices, get
+// an error.
+func RandomIdentity(deviceType DeviceType) (deviceId, error) {
+	keywords, ok := identityKeywords[deviceType]
+	if !ok {
+		return deviceId{}, fmt.Errorf("[error] no usb products known for device type %d", deviceType)
+	}
+	var candidates []usb.Product
+	for _, product := range usb.ProductsNamed(keywords.include...) {
+		name, excluded := strings.ToLower(product.Name), false
+		for _, exclude := range keywords.exclude {
+			excluded = excluded || strings.Contains(name, exclude)
+		}
+		if !excluded {
+			candidates = append(candidates, product)
+		}
+	}
+	if len(candidates) == 0 {
+		return deviceId{}, fmt.Errorf("[error] no usb products known for device type %d", deviceType)
+	}
+	identityRandom.Lock()
+	product := candidates[identityRandom.Intn(len(candidates))]
+	identityRandom.Unlock()
+	return deviceId{
+		busType: USB.Code(),
+		vendor:  product.VendorID,
+		product: product.ID,
+		version: 0x0111,
+	}, nil
+}
 
 type BusType uint16
 
@@ -91,3 +202,12 @@ func (bt BusType) Code() uint16 {
 func (bt BusType) Bus() uint16 {
 	return bt.UInt16()
 }
+
+// identity is the id set on the device, or the default id of deviceType when
+// none was, e.g. from RandomIdentity.
+func (self *Device) identity(deviceType DeviceType) deviceId {
+	if self.Id != (deviceId{}) {
+		return self.Id
+	}
+	return NewDeviceId(deviceType)
+}
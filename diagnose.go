@@ -0,0 +1,236 @@
+package uinput
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultUdevGroup is the group udev rules grant access to uinput, the one
+// distributions already give access to the other input devices.
+const DefaultUdevGroup = "input"
+
+// Paths of the udev rule and modules-load.d snippet from Diagnosis.
+const (
+	UdevRulePath    = "/etc/udev/rules.d/60-uinput.rules"
+	ModulesLoadPath = "/etc/modules-load.d/uinput.conf"
+)
+
+// capDacOverride is CAP_DAC_OVERRIDE from capability.h, which lets a process
+// open /dev/uinput whatever its mode.
+const capDacOverride = 1
+
+// Diagnosis explains whether, and why not, this process can create virtual
+// devices through uinput.
+type Diagnosis struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+	// ModuleLoaded is set when the uinput module is loaded or built in.
+	ModuleLoaded bool        `json:"module_loaded"`
+	Mode         os.FileMode `json:"mode,omitempty"`
+	Owner        string      `json:"owner,omitempty"`
+	Group        string      `json:"group,omitempty"`
+	// User and Groups are those of the calling process.
+	User   string   `json:"user"`
+	Groups []string `json:"groups"`
+	// Capabilities are the effective capabilities of the calling process.
+	Capabilities uint64 `json:"capabilities"`
+	// Writable is set when the device could be opened as OpenFileDescriptor
+	// does, for reading and writing, and OpenError tells why it could not
+	// otherwise.
+	Writable  bool     `json:"writable"`
+	OpenError string   `json:"open_error,omitempty"`
+	Advice    []string `json:"advice,omitempty"`
+}
+
+// Diagnose checks the uinput device node, the module providing it, its
+// permissions and the credentials of the calling process, and gives advice
+// on fixing what stops it from opening the device.
+func Diagnose() Diagnosis {
+	diagnosis := Diagnosis{Path: uinputPath, ModuleLoaded: uinputModuleLoaded()}
+	diagnosis.User, diagnosis.Groups = callerCredentials()
+	diagnosis.Capabilities = effectiveCapabilities()
+	if info, err := os.Stat(uinputPath); err == nil {
+		diagnosis.Exists = true
+		diagnosis.Mode = info.Mode()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			diagnosis.Owner = userName(int(stat.Uid))
+			diagnosis.Group = groupName(int(stat.Gid))
+		}
+	}
+	// NOTE: A device writable but not readable would pass a write-only probe
+	// and still fail in Connect.
+	if file, err := os.OpenFile(uinputPath, uinputOpenFlags, 0); err == nil {
+		file.Close()
+		diagnosis.Writable = true
+	} else {
+		diagnosis.OpenError = err.Error()
+	}
+	diagnosis.Advice = diagnosis.advice()
+	return diagnosis
+}
+
+// OK reports whether virtual devices can be created.
+func (self Diagnosis) OK() bool {
+	return self.Writable
+}
+
+func (self Diagnosis) advice() (advice []string) {
+	if self.Writable {
+		return nil
+	}
+	if !self.ModuleLoaded {
+		advice = append(advice,
+			"load the uinput module with `sudo modprobe uinput`",
+			fmt.Sprintf("load it on every boot by writing %q to %v", "uinput", ModulesLoadPath),
+		)
+	}
+	if !self.Exists {
+		if self.ModuleLoaded {
+			advice = append(advice, fmt.Sprintf("%v is missing although uinput is loaded; check that udev or devtmpfs is running", self.Path))
+		}
+		return advice
+	}
+	if self.Group != "" && !self.inGroup(self.Group) {
+		if self.Group == "root" {
+			advice = append(advice, fmt.Sprintf("give the %v group access with the udev rule below in %v, then `sudo udevadm control --reload && sudo udevadm trigger`", DefaultUdevGroup, UdevRulePath))
+			if !self.inGroup(DefaultUdevGroup) {
+				advice = append(advice, fmt.Sprintf("add yourself to the %v group with `sudo usermod -aG %v %v` and log in again", DefaultUdevGroup, DefaultUdevGroup, self.User))
+			}
+		} else {
+			advice = append(advice, fmt.Sprintf("add yourself to the %v group with `sudo usermod -aG %v %v` and log in again", self.Group, self.Group, self.User))
+		}
+	} else if self.Mode.Perm()&0060 != 0060 {
+		advice = append(advice, fmt.Sprintf("%v is %v, the group cannot read and write it; install the udev rule below in %v", self.Path, self.Mode.Perm(), UdevRulePath))
+	}
+	if self.Capabilities&(1<<capDacOverride) != 0 && len(advice) == 0 {
+		advice = append(advice, fmt.Sprintf("the device could not be opened despite CAP_DAC_OVERRIDE (%v); a security module such as SELinux or AppArmor may deny it", self.OpenError))
+	}
+	if len(advice) == 0 {
+		advice = append(advice, fmt.Sprintf("opening %v failed: %v", self.Path, self.OpenError))
+	}
+	return advice
+}
+
+func (self Diagnosis) inGroup(group string) bool {
+	for _, name := range self.Groups {
+		if name == group {
+			return true
+		}
+	}
+	return false
+}
+
+func (self Diagnosis) String() string {
+	var report strings.Builder
+	module, node := "not loaded", "missing"
+	if self.ModuleLoaded {
+		module = "loaded"
+	}
+	if self.Exists {
+		node = "ok"
+	}
+	fmt.Fprintf(&report, "uinput module:  %v\n", module)
+	fmt.Fprintf(&report, "%-15v %v\n", self.Path+":", node)
+	if self.Exists {
+		fmt.Fprintf(&report, "permissions:    %v %v:%v\n", self.Mode.Perm(), self.Owner, self.Group)
+	}
+	fmt.Fprintf(&report, "user:           %v (groups %v)\n", self.User, strings.Join(self.Groups, ", "))
+	fmt.Fprintf(&report, "capabilities:   %016x\n", self.Capabilities)
+	if self.Writable {
+		fmt.Fprintf(&report, "access:         ok, virtual devices can be created\n")
+		return report.String()
+	}
+	fmt.Fprintf(&report, "access:         denied (%v)\n", self.OpenError)
+	for _, advice := range self.Advice {
+		fmt.Fprintf(&report, "  - %v\n", advice)
+	}
+	fmt.Fprintf(&report, "\n%v:\n%v", UdevRulePath, UdevRule(DefaultUdevGroup))
+	fmt.Fprintf(&report, "\n%v:\n%v", ModulesLoadPath, ModulesLoadConfig())
+	return report.String()
+}
+
+// UdevRule returns a udev rule giving group read and write access to uinput.
+func UdevRule(group string) string {
+	return fmt.Sprintf("KERNEL==\"uinput\", SUBSYSTEM==\"misc\", MODE=\"0660\", GROUP=%q, OPTIONS+=\"static_node=uinput\"\n", group)
+}
+
+// ModulesLoadConfig returns the modules-load.d configuration loading uinput
+// on boot.
+func ModulesLoadConfig() string {
+	return "uinput\n"
+}
+
+// uinputModuleLoaded looks for uinput among the loaded modules, then among
+// the misc devices registered, which lists it when built into the kernel.
+func uinputModuleLoaded() bool {
+	if _, err := os.Stat("/sys/module/uinput"); err == nil {
+		return true
+	}
+	for _, path := range []string{"/proc/modules", "/proc/misc"} {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if fields := strings.Fields(scanner.Text()); len(fields) >= 2 &&
+				(fields[0] == "uinput" || fields[1] == "uinput") {
+				file.Close()
+				return true
+			}
+		}
+		file.Close()
+	}
+	return false
+}
+
+func callerCredentials() (string, []string) {
+	name := userName(os.Geteuid())
+	var groups []string
+	gids, _ := os.Getgroups()
+	gids = append([]int{os.Getegid()}, gids...)
+	seen := make(map[int]bool)
+	for _, gid := range gids {
+		if !seen[gid] {
+			seen[gid] = true
+			groups = append(groups, groupName(gid))
+		}
+	}
+	return name, groups
+}
+
+// effectiveCapabilities reads CapEff from /proc/self/status.
+func effectiveCapabilities() uint64 {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "CapEff:") {
+			capabilities, _ := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+			return capabilities
+		}
+	}
+	return 0
+}
+
+func userName(uid int) string {
+	if user, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		return user.Username
+	}
+	return strconv.Itoa(uid)
+}
+
+func groupName(gid int) string {
+	if group, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil {
+		return group.Name
+	}
+	return strconv.Itoa(gid)
+}
@@ -0,0 +1,175 @@
+package uinput
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// evdevIoctlBase is the ioctl type of the evdev interface in input.h.
+const evdevIoctlBase = 'E'
+
+// Request numbers of the evdev ioctls reading a device description.
+const (
+	evdevGetId       = 0x02 // EVIOCGID
+	evdevGetName     = 0x06 // EVIOCGNAME(len)
+	evdevGetPhys     = 0x07 // EVIOCGPHYS(len)
+	evdevGetProperty = 0x09 // EVIOCGPROP(len)
+	evdevGetKeyState = 0x18 // EVIOCGKEY(len)
+	evdevGetBits     = 0x20 // EVIOCGBIT(ev, len), offset by the event type
+	evdevGetAbs      = 0x40 // EVIOCGABS(abs), offset by the axis
+	evdevGetEffects  = 0x84 // EVIOCGEFFECTS
+	evdevGrab        = 0x90 // EVIOCGRAB
+)
+
+// evdevStringLength bounds the name and phys strings read from a device.
+const evdevStringLength = 256
+
+// EVIOCGABS(abs) from input.h, reading the input_absinfo of one axis.
+func absInfoRequest(axis EventCode) uintptr {
+	return ioc(iocRead, evdevIoctlBase, evdevGetAbs+uintptr(axis), unsafe.Sizeof(AbsInfo{}))
+}
+
+// AbsInfo mirrors struct input_absinfo from input.h, describing the range and
+// filtering of an absolute axis.
+type AbsInfo struct {
+	Value      int32 `json:"value,omitempty"`
+	Minimum    int32 `json:"min"`
+	Maximum    int32 `json:"max"`
+	Fuzz       int32 `json:"fuzz,omitempty"`
+	Flat       int32 `json:"flat,omitempty"`
+	Resolution int32 `json:"resolution,omitempty"`
+}
+
+// EventReader reads the events reported by an input event device such as
+// /dev/input/event3.
+type EventReader struct {
+	FD *os.File
+}
+
+func OpenEventReader(path string) (*EventReader, error) {
+	deviceFD, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("[error] could not open event device: %v", err)
+	}
+	return &EventReader{FD: deviceFD}, nil
+}
+
+// ReadEvent blocks until the device reports an event, or the reader is closed.
+func (self *EventReader) ReadEvent() (InputEvent, error) {
+	return readEvent(self.FD)
+}
+
+func (self *EventReader) AbsInfo(axis EventCode) (info AbsInfo, err error) {
+	if err = ioctl(self.FD, absInfoRequest(axis), uintptr(unsafe.Pointer(&info))); err != nil {
+		return info, fmt.Errorf("[error] failed to read axis %d info: %v", axis, err)
+	}
+	return info, nil
+}
+
+// PressedKeys reads the keys and buttons currently down on the device.
+func (self *EventReader) PressedKeys() ([]EventCode, error) {
+	keys, err := self.readBuffer(evdevGetKeyState, maxEventCodes[EV_KEY]/8+1)
+	if err != nil {
+		return nil, fmt.Errorf("[error] failed to read key state: %v", err)
+	}
+	return setBits(maskInt(keys)), nil
+}
+
+// readBuffer issues a variable length evdev read request into a buffer of
+// length bytes.
+func (self *EventReader) readBuffer(nr uintptr, length int) ([]byte, error) {
+	buffer := make([]byte, length)
+	if err := ioctl(self.FD, ioc(iocRead, evdevIoctlBase, nr, uintptr(length)), uintptr(unsafe.Pointer(&buffer[0]))); err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+func (self *EventReader) readString(nr uintptr) (string, error) {
+	buffer, err := self.readBuffer(nr, evdevStringLength)
+	if err != nil {
+		return "", err
+	}
+	if end := bytes.IndexByte(buffer, 0); end >= 0 {
+		buffer = buffer[:end]
+	}
+	return string(buffer), nil
+}
+
+// Description reads the identity, capabilities, properties and axis ranges
+// of the device straight from the kernel.
+func (self *EventReader) Description() (description DeviceDescription, err error) {
+	description.Capabilities = make(map[EventType][]EventCode)
+	description.Abs = make(map[EventCode]AbsInfo)
+	if description.Name, err = self.readString(evdevGetName); err != nil {
+		return description, fmt.Errorf("[error] failed to read device name: %v", err)
+	}
+	// NOTE: Virtual and some platform devices have no physical path.
+	description.Phys, _ = self.readString(evdevGetPhys)
+	if err = ioctl(self.FD, ioc(iocRead, evdevIoctlBase, evdevGetId, unsafe.Sizeof(deviceId{})), uintptr(unsafe.Pointer(&description.Id))); err != nil {
+		return description, fmt.Errorf("[error] failed to read device id: %v", err)
+	}
+	properties, err := self.readBuffer(evdevGetProperty, maxDeviceProperty/8+1)
+	if err != nil {
+		return description, fmt.Errorf("[error] failed to read device properties: %v", err)
+	}
+	for _, property := range setBits(maskInt(properties)) {
+		description.Properties = append(description.Properties, DeviceProperty(property))
+	}
+	eventTypes, err := self.readBuffer(evdevGetBits, maxEventCodes[EV_SYN]/8+1)
+	if err != nil {
+		return description, fmt.Errorf("[error] failed to read event types: %v", err)
+	}
+	for _, code := range setBits(maskInt(eventTypes)) {
+		eventType := MarshalEventType(int(code))
+		maxCode, ok := maxEventCodes[eventType]
+		if eventType == EV_SYN || !ok {
+			continue
+		}
+		codes, err := self.readBuffer(evdevGetBits+uintptr(code), maxCode/8+1)
+		if err != nil {
+			return description, fmt.Errorf("[error] failed to read %v codes: %v", eventType, err)
+		}
+		description.Capabilities[eventType] = setBits(maskInt(codes))
+	}
+	for _, axis := range description.Capabilities[EV_ABS] {
+		if description.Abs[axis], err = self.AbsInfo(axis); err != nil {
+			return description, err
+		}
+	}
+	if len(description.Capabilities[EV_FF]) > 0 {
+		var effects int32
+		if err = ioctl(self.FD, ioc(iocRead, evdevIoctlBase, evdevGetEffects, unsafe.Sizeof(effects)), uintptr(unsafe.Pointer(&effects))); err != nil {
+			return description, fmt.Errorf("[error] failed to read force feedback effect count: %v", err)
+		}
+		description.EffectsMax = uint32(effects)
+	}
+	return description, nil
+}
+
+// Grab takes the device for exclusive use: its events only reach this reader
+// until Ungrab is called or the reader is closed.
+func (self *EventReader) Grab() error {
+	if err := ioctl(self.FD, grabRequest(), 1); err != nil {
+		return fmt.Errorf("[error] failed to grab device: %v", err)
+	}
+	return nil
+}
+
+func (self *EventReader) Ungrab() error {
+	if err := ioctl(self.FD, grabRequest(), 0); err != nil {
+		return fmt.Errorf("[error] failed to release device: %v", err)
+	}
+	return nil
+}
+
+// EVIOCGRAB from input.h, passing the grab flag as the ioctl argument.
+func grabRequest() uintptr {
+	return ioc(iocWrite, evdevIoctlBase, evdevGrab, unsafe.Sizeof(int32(0)))
+}
+
+func (self *EventReader) Close() error {
+	return self.FD.Close()
+}
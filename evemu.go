@@ -0,0 +1,307 @@
+package uinput
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The evemu text format, as written by evemu-record and read by evemu-device
+// and evemu-play, so sessions can be attached to bug reports and replayed
+// with either tool. A description looks like:
+//
+//	# EVEMU 1.3
+//	N: Logitech USB Receiver
+//	I: 0003 046d c52b 0111
+//	P: 00 00 00 00 00 00 00 00
+//	B: 00 07 00 00 00 00 00 00 00
+//	B: 02 03 00 00 00 00 00 00 00
+//	A: 00 0 1920 0 0 0
+//
+// followed by one line per event, timed relative to the first event:
+//
+//	E: 0.000000 0002 0000 -001
+//	E: 0.000000 0000 0000 0000
+const evemuVersion = "1.3"
+
+type EvemuWriter struct {
+	w     io.Writer
+	start sessionStart
+}
+
+func NewEvemuWriter(w io.Writer) *EvemuWriter {
+	return &EvemuWriter{w: w}
+}
+
+func (self *EvemuWriter) WriteDescription(description DeviceDescription) error {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# EVEMU %v\n", evemuVersion)
+	fmt.Fprintf(&out, "# Input device name: %q\n", description.Name)
+	fmt.Fprintf(&out, "# Input device ID: bus 0x%02x vendor 0x%04x product 0x%04x version 0x%04x\n",
+		description.Id.busType, description.Id.vendor, description.Id.product, description.Id.version)
+	fmt.Fprintf(&out, "N: %v\n", description.Name)
+	fmt.Fprintf(&out, "I: %04x %04x %04x %04x\n",
+		description.Id.busType, description.Id.vendor, description.Id.product, description.Id.version)
+
+	properties := make([]EventCode, 0, len(description.Properties))
+	for _, property := range description.Properties {
+		properties = append(properties, EventCode(property))
+	}
+	writeEvemuMask(&out, "P:", maskBytes(properties, maxDeviceProperty))
+
+	// NOTE: evemu-record sets EV_SYN in the event type mask, as the kernel
+	// does for every device.
+	eventTypes := []EventCode{EventCode(EV_SYN.Code())}
+	for eventType := range description.Capabilities {
+		eventTypes = append(eventTypes, EventCode(eventType.Code()))
+	}
+	writeEvemuMask(&out, "B: 00", maskBytes(eventTypes, maxEventCodes[EV_SYN]))
+	for _, eventType := range sortedEventTypes(description.Capabilities) {
+		maxCode, ok := maxEventCodes[eventType]
+		if !ok || eventType == EV_SYN {
+			continue
+		}
+		prefix := fmt.Sprintf("B: %02x", eventType.Code())
+		writeEvemuMask(&out, prefix, maskBytes(description.Capabilities[eventType], maxCode))
+	}
+
+	axes := make([]EventCode, 0, len(description.Abs))
+	for axis := range description.Abs {
+		axes = append(axes, axis)
+	}
+	sort.Slice(axes, func(i, j int) bool { return axes[i] < axes[j] })
+	for _, axis := range axes {
+		info := description.Abs[axis]
+		fmt.Fprintf(&out, "A: %02x %d %d %d %d %d\n",
+			axis, info.Minimum, info.Maximum, info.Fuzz, info.Flat, info.Resolution)
+	}
+	_, err := io.WriteString(self.w, out.String())
+	return err
+}
+
+// WriteEvent writes the event timed relative to the first written event.
+func (self *EvemuWriter) WriteEvent(event InputEvent) error {
+	offset := self.start.offset(event)
+	_, err := fmt.Fprintf(self.w, "E: %d.%06d %04x %04x %04d\n",
+		offset.Sec, offset.Usec, event.Type, event.Code, event.Value)
+	return err
+}
+
+func sortedEventTypes(capabilities map[EventType][]EventCode) []EventType {
+	eventTypes := make([]EventType, 0, len(capabilities))
+	for eventType := range capabilities {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Slice(eventTypes, func(i, j int) bool { return eventTypes[i] < eventTypes[j] })
+	return eventTypes
+}
+
+// maskBytes packs codes into a little endian bitmask large enough for maxCode.
+func maskBytes(codes []EventCode, maxCode int) []byte {
+	mask := make([]byte, maxCode/8+1)
+	for _, code := range codes {
+		if int(code) <= maxCode {
+			mask[code/8] |= 1 << (code % 8)
+		}
+	}
+	return mask
+}
+
+// writeEvemuMask writes a bitmask eight bytes per line, zero padding the last.
+func writeEvemuMask(out *strings.Builder, prefix string, mask []byte) {
+	for offset := 0; offset < len(mask); offset += 8 {
+		out.WriteString(prefix)
+		for n := offset; n < offset+8; n++ {
+			if n < len(mask) {
+				fmt.Fprintf(out, " %02x", mask[n])
+			} else {
+				out.WriteString(" 00")
+			}
+		}
+		out.WriteString("\n")
+	}
+}
+
+type EvemuReader struct {
+	scanner *bufio.Scanner
+	line    int
+	pending string // first event line, read while looking for the description
+}
+
+func NewEvemuReader(r io.Reader) *EvemuReader {
+	return &EvemuReader{scanner: bufio.NewScanner(r)}
+}
+
+// next returns the next line that is neither blank nor a comment.
+func (self *EvemuReader) next() (string, error) {
+	if line := self.pending; line != "" {
+		self.pending = ""
+		return line, nil
+	}
+	for self.scanner.Scan() {
+		self.line++
+		line := self.scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 && !strings.HasPrefix(line, "N:") {
+			line = line[:comment]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+	if err := self.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+func (self *EvemuReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("[error] evemu line %d: %v", self.line, fmt.Sprintf(format, args...))
+}
+
+func (self *EvemuReader) ReadDescription() (DeviceDescription, error) {
+	description := DeviceDescription{
+		Capabilities: make(map[EventType][]EventCode),
+		Abs:          make(map[EventCode]AbsInfo),
+	}
+	masks := make(map[uint16][]byte)
+	var properties []byte
+	for {
+		line, err := self.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return description, err
+		}
+		if strings.HasPrefix(line, "E:") {
+			self.pending = line
+			break
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "N:":
+			description.Name = strings.TrimSpace(strings.TrimPrefix(line, "N:"))
+		case "I:":
+			values, err := parseEvemuFields(fields[1:], 16, 4)
+			if err != nil {
+				return description, self.errorf("invalid id: %v", err)
+			}
+			description.Id = deviceId{
+				busType: uint16(values[0]),
+				vendor:  uint16(values[1]),
+				product: uint16(values[2]),
+				version: uint16(values[3]),
+			}
+		case "P:":
+			values, err := parseEvemuFields(fields[1:], 16, -1)
+			if err != nil {
+				return description, self.errorf("invalid properties: %v", err)
+			}
+			for _, value := range values {
+				properties = append(properties, byte(value))
+			}
+		case "B:":
+			values, err := parseEvemuFields(fields[1:], 16, -1)
+			if err != nil || len(values) == 0 {
+				return description, self.errorf("invalid bitmask: %v", err)
+			}
+			eventType := uint16(values[0])
+			for _, value := range values[1:] {
+				masks[eventType] = append(masks[eventType], byte(value))
+			}
+		case "A:":
+			if len(fields) < 6 {
+				return description, self.errorf("invalid axis %q", line)
+			}
+			axis, err := strconv.ParseUint(fields[1], 16, 16)
+			if err != nil {
+				return description, self.errorf("invalid axis code: %v", err)
+			}
+			values, err := parseEvemuFields(fields[2:], 10, -1)
+			if err != nil {
+				return description, self.errorf("invalid axis: %v", err)
+			}
+			info := AbsInfo{Minimum: int32(values[0]), Maximum: int32(values[1]), Fuzz: int32(values[2]), Flat: int32(values[3])}
+			if len(values) > 4 {
+				info.Resolution = int32(values[4])
+			}
+			description.Abs[EventCode(axis)] = info
+		}
+		// NOTE: L: and S: lines carry the LED and switch state at the time of
+		// the recording, which replaying the events restores anyway.
+	}
+	for _, eventType := range setBits(maskInt(masks[EV_SYN.Code()])) {
+		kind := MarshalEventType(int(eventType))
+		if kind == EV_SYN {
+			continue
+		}
+		description.Capabilities[kind] = setBits(maskInt(masks[uint16(eventType)]))
+	}
+	for _, property := range setBits(maskInt(properties)) {
+		description.Properties = append(description.Properties, DeviceProperty(property))
+	}
+	return description, nil
+}
+
+func (self *EvemuReader) ReadEvent() (event InputEvent, err error) {
+	line, err := self.next()
+	if err != nil {
+		return event, err
+	}
+	fields := strings.Fields(line)
+	if fields[0] != "E:" || len(fields) < 5 {
+		return event, self.errorf("expected an event, found %q", line)
+	}
+	// NOTE: The digits after the point are a fraction of a second, "1.5" is
+	// half a second whatever the padding.
+	seconds, fraction, found := strings.Cut(fields[1], ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || !found || fraction == "" || len(fraction) > 9 || strings.Trim(fraction, "0123456789") != "" {
+		return event, self.errorf("invalid time %q", fields[1])
+	}
+	nsec, err := strconv.ParseInt((fraction + "000000000")[:9], 10, 64)
+	if err != nil {
+		return event, self.errorf("invalid time %q", fields[1])
+	}
+	values, err := parseEvemuFields(fields[2:4], 16, 2)
+	if err != nil {
+		return event, self.errorf("invalid event: %v", err)
+	}
+	value, err := strconv.ParseInt(fields[4], 10, 32)
+	if err != nil {
+		return event, self.errorf("invalid event value: %v", err)
+	}
+	event.Time = NsecToTimeval(sec*1e9 + nsec)
+	event.Type, event.Code, event.Value = uint16(values[0]), uint16(values[1]), int32(value)
+	return event, nil
+}
+
+// parseEvemuFields parses fields in base, requiring count of them unless
+// count is negative.
+func parseEvemuFields(fields []string, base, count int) ([]int64, error) {
+	if count >= 0 && len(fields) < count {
+		return nil, fmt.Errorf("expected %d values, found %d", count, len(fields))
+	}
+	values := make([]int64, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseInt(field, base, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// maskInt converts a little endian bitmask to the big.Int used for procfs
+// bitfields.
+func maskInt(mask []byte) *big.Int {
+	reversed := make([]byte, len(mask))
+	for n, b := range mask {
+		reversed[len(mask)-1-n] = b
+	}
+	return new(big.Int).SetBytes(reversed)
+}
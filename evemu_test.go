@@ -0,0 +1,102 @@
+package uinput
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var evemuDescription = DeviceDescription{
+	Name:       "Test Tablet",
+	Id:         deviceId{busType: 0x03, vendor: 0x046d, product: 0xc52b, version: 0x0111},
+	Properties: []DeviceProperty{INPUT_PROP_DIRECT},
+	Capabilities: map[EventType][]EventCode{
+		EV_KEY: {KEY_A, BTN_LEFT},
+		EV_REL: {REL_WHEEL},
+		EV_ABS: {ABS_X, ABS_Y},
+	},
+	Abs: map[EventCode]AbsInfo{
+		ABS_X: {Maximum: 1920, Resolution: 12},
+		ABS_Y: {Minimum: -5, Maximum: 1080, Fuzz: 2, Flat: 1},
+	},
+}
+
+func TestEvemuDescription(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewEvemuWriter(&out).WriteDescription(evemuDescription); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: EV_SYN, EV_KEY, EV_REL and EV_ABS are bits 0 to 3.
+	if !strings.Contains(out.String(), "\nB: 00 0f 00 00 00 00 00 00 00\n") {
+		t.Errorf("event type mask without EV_SYN:\n%s", out.String())
+	}
+	for _, line := range []string{"N: Test Tablet\n", "I: 0003 046d c52b 0111\n", "P: 02 00 00 00 00 00 00 00\n", "A: 00 0 1920 0 0 12\n", "A: 01 -5 1080 2 1 0\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("missing %q in:\n%s", line, out.String())
+		}
+	}
+	description, err := NewEvemuReader(&out).ReadDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(description, evemuDescription) {
+		t.Errorf("read back %+v, want %+v", description, evemuDescription)
+	}
+}
+
+func TestEvemuEvents(t *testing.T) {
+	events := []InputEvent{
+		{Time: Timeval{Sec: 100, Usec: 250000}, Type: EV_REL.Code(), Code: uint16(REL_WHEEL), Value: -1},
+		{Time: Timeval{Sec: 100, Usec: 250000}, Type: EV_SYN.Code()},
+		{Time: Timeval{Sec: 102, Usec: 1}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1},
+	}
+	var out bytes.Buffer
+	writer := NewEvemuWriter(&out)
+	for _, event := range events {
+		if err := writer.WriteEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "E: 0.000000 0002 0008 -001\nE: 0.000000 0000 0000 0000\nE: 1.750001 0001 001e 0001\n"
+	if out.String() != want {
+		t.Errorf("wrote %q, want %q", out.String(), want)
+	}
+	reader := NewEvemuReader(&out)
+	for n, event := range events {
+		read, err := reader.ReadEvent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.Time = NsecToTimeval(event.Time.Nano() - events[0].Time.Nano())
+		if read != event {
+			t.Errorf("event %d read as %+v, want %+v", n, read, event)
+		}
+	}
+}
+
+func TestEvemuEventTime(t *testing.T) {
+	for _, test := range []struct {
+		time string
+		want Timeval
+		ok   bool
+	}{
+		{"0.000001", Timeval{0, 1}, true},
+		{"1.5", Timeval{1, 500000}, true},
+		{"2.25000", Timeval{2, 250000}, true},
+		{"3.0000015", Timeval{3, 2}, true},
+		{"4.000000001", Timeval{4, 1}, true},
+		{"5", Timeval{}, false},
+		{"5.", Timeval{}, false},
+		{"5.-1", Timeval{}, false},
+		{"5.1234567890", Timeval{}, false},
+		{"x.5", Timeval{}, false},
+	} {
+		event, err := NewEvemuReader(strings.NewReader("E: " + test.time + " 0001 001e 0001\n")).ReadEvent()
+		if (err == nil) != test.ok {
+			t.Errorf("%q: error %v, want ok %v", test.time, err, test.ok)
+		} else if test.ok && event.Time != test.want {
+			t.Errorf("%q: read %+v, want %+v", test.time, event.Time, test.want)
+		}
+	}
+}
@@ -1,59 +1,239 @@
 package uinput
 
 import (
-	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
-	"os"
-	"syscall"
+	"io"
+	"math"
+	"strconv"
+	"time"
 )
 
 // translated to go from input.h
 type InputEvent struct {
-	Time  syscall.Timeval // TODO: Would prefer time.Time with ability to ouput as syscall.Timeval
+	Time  Timeval // Use Timestamp and SetTimestamp to work with time.Time
 	Type  uint16
 	Code  uint16
 	Value int32
 }
 
-func (self Device) SyncEvents() error {
-	if eventBuffer, err := appendEvent(InputEvent{
-		Time:  syscall.Timeval{Sec: 0, Usec: 0},
+// jsonEvent is the JSON form of an InputEvent, using symbolic names and the
+// time in seconds, e.g. {"t":0.0125,"type":"EV_KEY","code":"KEY_A","value":1}.
+type jsonEvent struct {
+	Time  float64 `json:"t"`
+	Type  string  `json:"type"`
+	Code  string  `json:"code"`
+	Value int32   `json:"value"`
+}
+
+// Timestamp returns the time of the event.
+func (self InputEvent) Timestamp() time.Time {
+	return time.Unix(self.Time.Unix())
+}
+
+// SetTimestamp sets the time of the event, rounded up to the microsecond.
+func (self *InputEvent) SetTimestamp(timestamp time.Time) {
+	self.Time = NsecToTimeval(timestamp.UnixNano())
+}
+
+func (self InputEvent) eventType() (EventType, bool) {
+	eventType := MarshalEventType(int(self.Type))
+	return eventType, eventType.Code() == self.Type
+}
+
+func (self InputEvent) MarshalJSON() ([]byte, error) {
+	event := jsonEvent{
+		Time:  float64(self.Time.Nano()) / 1e9,
+		Type:  fmt.Sprintf("%d", self.Type),
+		Code:  fmt.Sprintf("%d", self.Code),
+		Value: self.Value,
+	}
+	if eventType, ok := self.eventType(); ok {
+		event.Type = eventType.String()
+		event.Code = CodeName(eventType, EventCode(self.Code))
+	}
+	return json.Marshal(event)
+}
+
+func (self *InputEvent) UnmarshalJSON(data []byte) error {
+	var event jsonEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	self.Time = NsecToTimeval(int64(math.Round(event.Time*1e6)) * 1e3)
+	self.Value = event.Value
+	eventType, err := ParseEventType(event.Type)
+	if err != nil {
+		// NOTE: MarshalJSON writes the types without a name, and their
+		// codes, as numbers.
+		number, numberErr := strconv.ParseUint(event.Type, 0, 16)
+		if numberErr != nil {
+			return err
+		}
+		code, err := strconv.ParseUint(event.Code, 0, 16)
+		if err != nil {
+			return fmt.Errorf("[error] unknown event code %q", event.Code)
+		}
+		self.Type, self.Code = uint16(number), uint16(code)
+		return nil
+	}
+	code, err := ParseEventCode(eventType, event.Code)
+	if err != nil {
+		return err
+	}
+	self.Type, self.Code = eventType.Code(), uint16(code)
+	return nil
+}
+
+// eventBinarySize is the length of the MarshalBinary form of an InputEvent: the
+// time in microseconds, type, code and value, little endian and independent of
+// the platform input_event layout.
+const eventBinarySize = 16
+
+func (self InputEvent) MarshalBinary() ([]byte, error) {
+	data := make([]byte, eventBinarySize)
+	binary.LittleEndian.PutUint64(data[0:], uint64(self.Time.Nano()/1e3))
+	binary.LittleEndian.PutUint16(data[8:], self.Type)
+	binary.LittleEndian.PutUint16(data[10:], self.Code)
+	binary.LittleEndian.PutUint32(data[12:], uint32(self.Value))
+	return data, nil
+}
+
+func (self *InputEvent) UnmarshalBinary(data []byte) error {
+	if len(data) != eventBinarySize {
+		return fmt.Errorf("[error] input event must be %d bytes, got %d", eventBinarySize, len(data))
+	}
+	self.Time = NsecToTimeval(int64(binary.LittleEndian.Uint64(data[0:])) * 1e3)
+	self.Type = binary.LittleEndian.Uint16(data[8:])
+	self.Code = binary.LittleEndian.Uint16(data[10:])
+	self.Value = int32(binary.LittleEndian.Uint32(data[12:]))
+	return nil
+}
+
+// SyncEvents reports an empty frame, a lone SYN_REPORT.
+func (self *Device) SyncEvents() error {
+	return self.writeFrame()
+}
+
+func readEvent(r io.Reader) (InputEvent, error) {
+	return NativeEventLayout.Read(r)
+}
+
+// writeFrame writes events followed by a SYN_REPORT with a single write,
+// holding the device frames lock so frames of concurrent callers never
+// interleave.
+func (self *Device) writeFrame(events ...InputEvent) error {
+	self.frames.Lock()
+	defer self.frames.Unlock()
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.writeFrameLocked(events...)
+}
+
+// writeFrameLocked is writeFrame for callers already holding the frames lock
+// and the mutex. The mutex is released while waiting on EventsPerSecond.
+func (self *Device) writeFrameLocked(events ...InputEvent) error {
+	if self.FD == nil {
+		return ErrDisconnected
+	}
+	filtered, err := self.state.filter(events, self.RedundantEvents)
+	if err != nil {
+		return err
+	}
+	if len(filtered) == 0 && len(events) > 0 {
+		return nil
+	}
+	events = filtered
+	// NOTE: A lone SYN_REPORT counts as one event, or flooding with them
+	// would go unnoticed.
+	count := len(events)
+	if count == 0 {
+		count = 1
+	}
+	var wait time.Duration
+	if !self.releasesHeld(events) {
+		if wait, err = self.limit(count); err == errDropped {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	if wait > 0 {
+		// NOTE: Only the frames lock stays held while waiting, so Disconnect,
+		// ReleaseAll and the MaxHold releases go ahead of the frame.
+		self.mutex.Unlock()
+		self.clock().Sleep(wait)
+		self.mutex.Lock()
+		if self.FD == nil {
+			return ErrDisconnected
+		}
+	}
+	return self.writeEventsLocked(events)
+}
+
+// writeEventsLocked writes a frame past the redundant event policy and the
+// device limits.
+func (self *Device) writeEventsLocked(events []InputEvent) error {
+	if self.FD == nil {
+		return ErrDisconnected
+	}
+	// NOTE: The kernel stamps the events injected through uinput itself, the
+	// time only matters to the backends keeping events as written.
+	now := NsecToTimeval(self.clock().Now().UnixNano())
+	stamped := make([]InputEvent, 0, len(events)+1)
+	for _, event := range events {
+		event.Time = now
+		stamped = append(stamped, event)
+	}
+	stamped = append(stamped, InputEvent{
+		Time:  now,
 		Type:  evSync.UInt16(),
 		Code:  0,
 		Value: ReportSync.Int32(),
-	}); err != nil {
-		return fmt.Errorf("[error] writing sync event failed: %v", err)
-	} else {
-		if _, err = self.FD.Write(eventBuffer); err != nil {
+	})
+	if self.Trace.enabled() {
+		if err := self.Trace.frame(self.name(), stamped); err != nil {
 			return err
 		}
 	}
+	if !self.Trace.DryRun {
+		frame := make([]byte, 0, len(stamped)*NativeEventLayout.Size())
+		for _, event := range stamped {
+			frame = NativeEventLayout.Append(frame, event)
+		}
+		if _, err := self.FD.Write(frame); err != nil {
+			return fmt.Errorf("[error] failed to write frame to device file: %v", err)
+		}
+	}
+	self.state.track(events)
+	self.watchHolds(events)
 	return nil
 }
 
-func appendEvent(event InputEvent) (buffer []byte, err error) {
-	eventBuffer := new(bytes.Buffer)
-	if err = binary.Write(eventBuffer, binary.LittleEndian, event); err != nil {
-		return nil, fmt.Errorf("[error] failed to write input event to buffer: %v", err)
+// frameWriter collects events written one at a time, as read from a device
+// or a session, into frames written to the device on each SYN_REPORT.
+type frameWriter struct {
+	device *Device
+	frame  []InputEvent
+}
+
+func (self *frameWriter) Event(event InputEvent) error {
+	if event.Type == EV_SYN.Code() && event.Code == uint16(ReportSync) {
+		frame := self.frame
+		self.frame = nil
+		return self.device.writeFrame(frame...)
 	}
-	return eventBuffer.Bytes(), nil
+	self.frame = append(self.frame, event)
+	return nil
 }
 
 // Note that mice and touch pads do have buttons as well. Therefore, this function is used
 // by all currently available devices and resides in the main source file.
-func sendButtonEvent(deviceFD *os.File, key int, buttonState int) error {
-	if eventBuffer, err := appendEvent(InputEvent{
-		Time:  syscall.Timeval{Sec: 0, Usec: 0},
+func buttonInputEvent(key EventCode, buttonState int) InputEvent {
+	return InputEvent{
 		Type:  keyEvent.UInt16(),
 		Code:  uint16(key),
 		Value: int32(buttonState),
-	}); err != nil {
-		return fmt.Errorf("key event could not be set: %v", err)
-	} else {
-		if _, err = deviceFD.Write(eventBuffer); err != nil {
-			return fmt.Errorf("[error] writing buttonEvent structure to the device file failed: %v", err)
-		}
 	}
-	return nil
 }
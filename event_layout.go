@@ -0,0 +1,127 @@
+package uinput
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// Timeval is the time of an input event. Unlike syscall.Timeval its fields
+// are 64 bit on every platform, so times past 2038 survive on 32 bit ones.
+type Timeval struct {
+	Sec  int64
+	Usec int64
+}
+
+// NsecToTimeval converts nanoseconds into a Timeval, rounding up to the
+// microsecond as syscall.NsecToTimeval does. Times before 1970 have negative
+// seconds and positive microseconds, -1.5µs being -1s and 999999µs.
+func NsecToTimeval(nsec int64) Timeval {
+	// NOTE: Go divisions truncate toward zero, so rounding up negative times
+	// takes a floor division rather than the one of syscall.NsecToTimeval.
+	usec := (nsec + 999) / 1e3
+	if (nsec+999)%1e3 < 0 {
+		usec--
+	}
+	sec := usec / 1e6
+	usec %= 1e6
+	if usec < 0 {
+		usec += 1e6
+		sec--
+	}
+	return Timeval{Sec: sec, Usec: usec}
+}
+
+func (self Timeval) Nano() int64 {
+	return self.Sec*1e9 + self.Usec*1e3
+}
+
+func (self Timeval) Unix() (sec, nsec int64) {
+	return self.Sec, self.Usec * 1e3
+}
+
+// EventLayout is the layout of struct input_event from input.h on a platform:
+// the seconds and microseconds are a kernel long each, followed by the type,
+// code and value.
+//
+// NOTE: With a 64 bit time_t, 32 bit platforms (armv7, i386) did not switch
+// to 64 bit fields; input.h declares them __kernel_ulong_t instead of a
+// timeval there, so the seconds are unsigned and last until 2106.
+type EventLayout struct {
+	WordSize  int
+	ByteOrder binary.ByteOrder
+}
+
+var (
+	// EventLayout32 is the input_event of i386 and armv7.
+	EventLayout32 = EventLayout{WordSize: 4, ByteOrder: binary.LittleEndian}
+	// EventLayout64 is the input_event of amd64 and arm64.
+	EventLayout64 = EventLayout{WordSize: 8, ByteOrder: binary.LittleEndian}
+	// NativeEventLayout is the input_event of the running platform, used to
+	// write to uinput and read from evdev devices.
+	NativeEventLayout = EventLayout{
+		WordSize:  int(unsafe.Sizeof(uintptr(0))),
+		ByteOrder: nativeByteOrder(),
+	}
+)
+
+func nativeByteOrder() binary.ByteOrder {
+	value := uint16(1)
+	if *(*byte)(unsafe.Pointer(&value)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// Size is the length of an encoded input_event.
+func (self EventLayout) Size() int {
+	return 2*self.WordSize + 8
+}
+
+// Append appends the encoded event to data.
+func (self EventLayout) Append(data []byte, event InputEvent) []byte {
+	start := len(data)
+	data = append(data, make([]byte, self.Size())...)
+	encoded := data[start:]
+	if self.WordSize == 4 {
+		self.ByteOrder.PutUint32(encoded[0:], uint32(event.Time.Sec))
+		self.ByteOrder.PutUint32(encoded[4:], uint32(event.Time.Usec))
+	} else {
+		self.ByteOrder.PutUint64(encoded[0:], uint64(event.Time.Sec))
+		self.ByteOrder.PutUint64(encoded[8:], uint64(event.Time.Usec))
+	}
+	offset := 2 * self.WordSize
+	self.ByteOrder.PutUint16(encoded[offset:], event.Type)
+	self.ByteOrder.PutUint16(encoded[offset+2:], event.Code)
+	self.ByteOrder.PutUint32(encoded[offset+4:], uint32(event.Value))
+	return data
+}
+
+// Decode decodes an event of exactly Size bytes.
+func (self EventLayout) Decode(data []byte) (event InputEvent, err error) {
+	if len(data) != self.Size() {
+		return event, fmt.Errorf("[error] input_event must be %d bytes, got %d", self.Size(), len(data))
+	}
+	if self.WordSize == 4 {
+		event.Time.Sec = int64(self.ByteOrder.Uint32(data[0:]))
+		event.Time.Usec = int64(self.ByteOrder.Uint32(data[4:]))
+	} else {
+		event.Time.Sec = int64(self.ByteOrder.Uint64(data[0:]))
+		event.Time.Usec = int64(self.ByteOrder.Uint64(data[8:]))
+	}
+	offset := 2 * self.WordSize
+	event.Type = self.ByteOrder.Uint16(data[offset:])
+	event.Code = self.ByteOrder.Uint16(data[offset+2:])
+	event.Value = int32(self.ByteOrder.Uint32(data[offset+4:]))
+	return event, nil
+}
+
+// Read reads and decodes the next event from r.
+func (self EventLayout) Read(r io.Reader) (InputEvent, error) {
+	data := make([]byte, self.Size())
+	if _, err := io.ReadFull(r, data); err != nil {
+		return InputEvent{}, err
+	}
+	return self.Decode(data)
+}
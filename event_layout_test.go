@@ -0,0 +1,161 @@
+package uinput
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+var eventLayouts = []struct {
+	name   string
+	layout EventLayout
+}{
+	{"32 bit little endian", EventLayout32},
+	{"32 bit big endian", EventLayout{WordSize: 4, ByteOrder: binary.BigEndian}},
+	{"64 bit little endian", EventLayout64},
+	{"64 bit big endian", EventLayout{WordSize: 8, ByteOrder: binary.BigEndian}},
+}
+
+func TestEventLayoutEncoding(t *testing.T) {
+	event := InputEvent{Time: Timeval{Sec: 0x01020304, Usec: 0x0506}, Type: 0x0102, Code: 0x0304, Value: -2}
+	for _, test := range []struct {
+		layout EventLayout
+		data   []byte
+	}{
+		{EventLayout32, []byte{
+			0x04, 0x03, 0x02, 0x01, 0x06, 0x05, 0x00, 0x00,
+			0x02, 0x01, 0x04, 0x03, 0xfe, 0xff, 0xff, 0xff,
+		}},
+		{EventLayout{WordSize: 4, ByteOrder: binary.BigEndian}, []byte{
+			0x01, 0x02, 0x03, 0x04, 0x00, 0x00, 0x05, 0x06,
+			0x01, 0x02, 0x03, 0x04, 0xff, 0xff, 0xff, 0xfe,
+		}},
+		{EventLayout64, []byte{
+			0x04, 0x03, 0x02, 0x01, 0x00, 0x00, 0x00, 0x00,
+			0x06, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x02, 0x01, 0x04, 0x03, 0xfe, 0xff, 0xff, 0xff,
+		}},
+		{EventLayout{WordSize: 8, ByteOrder: binary.BigEndian}, []byte{
+			0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x06,
+			0x01, 0x02, 0x03, 0x04, 0xff, 0xff, 0xff, 0xfe,
+		}},
+	} {
+		if size := test.layout.Size(); size != len(test.data) {
+			t.Errorf("%d bit %v: size %d, want %d", 8*test.layout.WordSize, test.layout.ByteOrder, size, len(test.data))
+		}
+		if data := test.layout.Append(nil, event); !bytes.Equal(data, test.data) {
+			t.Errorf("%d bit %v: encoded % x, want % x", 8*test.layout.WordSize, test.layout.ByteOrder, data, test.data)
+		}
+	}
+}
+
+func TestEventLayoutRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		event InputEvent
+		// wide events only round trip on 64 bit layouts.
+		wide bool
+	}{
+		{name: "zero"},
+		{name: "key press", event: InputEvent{Time: Timeval{Sec: 1700000000, Usec: 123456}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1}},
+		{name: "negative value", event: InputEvent{Time: Timeval{Sec: 1, Usec: 999999}, Type: EV_REL.Code(), Code: uint16(REL_X), Value: -10}},
+		{name: "extreme values", event: InputEvent{Type: math.MaxUint16, Code: math.MaxUint16, Value: math.MinInt32}},
+		{name: "after 2038", event: InputEvent{Time: Timeval{Sec: math.MaxInt32 + 1, Usec: 1}, Type: EV_SYN.Code()}},
+		{name: "unsigned 32 bit seconds", event: InputEvent{Time: Timeval{Sec: math.MaxUint32, Usec: 999999}, Type: EV_SYN.Code()}},
+		{name: "after 2106", event: InputEvent{Time: Timeval{Sec: math.MaxUint32 + 1}, Type: EV_SYN.Code()}, wide: true},
+		{name: "before 1970", event: InputEvent{Time: Timeval{Sec: -1, Usec: 500000}, Type: EV_SYN.Code()}, wide: true},
+	} {
+		for _, layout := range eventLayouts {
+			if test.wide && layout.layout.WordSize == 4 {
+				continue
+			}
+			t.Run(test.name+"/"+layout.name, func(t *testing.T) {
+				data := layout.layout.Append([]byte{0xaa}, test.event)
+				if len(data) != 1+layout.layout.Size() || data[0] != 0xaa {
+					t.Fatalf("appended % x to a byte", data)
+				}
+				event, err := layout.layout.Decode(data[1:])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if event != test.event {
+					t.Errorf("decoded %+v, want %+v", event, test.event)
+				}
+				if event, err = layout.layout.Read(bytes.NewReader(data[1:])); err != nil || event != test.event {
+					t.Errorf("read %+v %v, want %+v", event, err, test.event)
+				}
+			})
+		}
+	}
+}
+
+func TestEventLayoutShortData(t *testing.T) {
+	for _, layout := range eventLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			data := layout.layout.Append(nil, InputEvent{Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1})
+			if _, err := layout.layout.Read(bytes.NewReader(nil)); err != io.EOF {
+				t.Errorf("reading nothing failed with %v, want io.EOF", err)
+			}
+			if _, err := layout.layout.Read(bytes.NewReader(data[:len(data)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("reading a short event failed with %v, want io.ErrUnexpectedEOF", err)
+			}
+			if _, err := layout.layout.Decode(data[:len(data)-1]); err == nil {
+				t.Error("decoded a short event")
+			}
+			if _, err := layout.layout.Decode(append(data, 0)); err == nil {
+				t.Error("decoded a long event")
+			}
+			reader := bytes.NewReader(append(append([]byte(nil), data...), data[:3]...))
+			if _, err := layout.layout.Read(reader); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := layout.layout.Read(reader); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("reading a truncated second event failed with %v, want io.ErrUnexpectedEOF", err)
+			}
+		})
+	}
+}
+
+func TestNsecToTimeval(t *testing.T) {
+	for _, test := range []struct {
+		nsec int64
+		want Timeval
+	}{
+		{0, Timeval{0, 0}},
+		{1, Timeval{0, 1}},
+		{1000, Timeval{0, 1}},
+		{1001, Timeval{0, 2}},
+		{999999999, Timeval{1, 0}},
+		{1500000000, Timeval{1, 500000}},
+		{(math.MaxInt32 + 1) * 1e9, Timeval{math.MaxInt32 + 1, 0}},
+		{-1, Timeval{0, 0}},
+		{-999, Timeval{0, 0}},
+		{-1000, Timeval{-1, 999999}},
+		{-1500, Timeval{-1, 999999}},
+		{-2000, Timeval{-1, 999998}},
+		{-1e9, Timeval{-1, 0}},
+		{-1500000000, Timeval{-2, 500000}},
+	} {
+		if got := NsecToTimeval(test.nsec); got != test.want {
+			t.Errorf("NsecToTimeval(%d) = %+v, want %+v", test.nsec, got, test.want)
+		}
+	}
+}
+
+func TestTimeval(t *testing.T) {
+	timeval := Timeval{Sec: -2, Usec: 500000}
+	if nano := timeval.Nano(); nano != -1500000000 {
+		t.Errorf("Nano() = %d, want -1500000000", nano)
+	}
+	if sec, nsec := timeval.Unix(); sec != -2 || nsec != 500000000 {
+		t.Errorf("Unix() = %d, %d, want -2, 500000000", sec, nsec)
+	}
+	if timeval := NsecToTimeval(timeval.Nano()); !reflect.DeepEqual(timeval, Timeval{Sec: -2, Usec: 500000}) {
+		t.Errorf("round trip through nanoseconds gave %+v", timeval)
+	}
+}
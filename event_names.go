@@ -0,0 +1,648 @@
+// Code generated by gen/gen_event_names.go from event_codes.go. DO NOT EDIT.
+
+package uinput
+
+// eventCodeNames lists every named event code. When several names share a
+// code the last one listed is canonical, the earlier ones mark the start of
+// a range, e.g. BTN_MOUSE and BTN_LEFT.
+var eventCodeNames = []eventCodeName{
+	{EV_KEY, KEY_RESERVED, "KEY_RESERVED"},
+	{EV_KEY, KEY_ESC, "KEY_ESC"},
+	{EV_KEY, KEY_1, "KEY_1"},
+	{EV_KEY, KEY_2, "KEY_2"},
+	{EV_KEY, KEY_3, "KEY_3"},
+	{EV_KEY, KEY_4, "KEY_4"},
+	{EV_KEY, KEY_5, "KEY_5"},
+	{EV_KEY, KEY_6, "KEY_6"},
+	{EV_KEY, KEY_7, "KEY_7"},
+	{EV_KEY, KEY_8, "KEY_8"},
+	{EV_KEY, KEY_9, "KEY_9"},
+	{EV_KEY, KEY_0, "KEY_0"},
+	{EV_KEY, KEY_MINUS, "KEY_MINUS"},
+	{EV_KEY, KEY_EQUAL, "KEY_EQUAL"},
+	{EV_KEY, KEY_BACKSPACE, "KEY_BACKSPACE"},
+	{EV_KEY, KEY_TAB, "KEY_TAB"},
+	{EV_KEY, KEY_Q, "KEY_Q"},
+	{EV_KEY, KEY_W, "KEY_W"},
+	{EV_KEY, KEY_E, "KEY_E"},
+	{EV_KEY, KEY_R, "KEY_R"},
+	{EV_KEY, KEY_T, "KEY_T"},
+	{EV_KEY, KEY_Y, "KEY_Y"},
+	{EV_KEY, KEY_U, "KEY_U"},
+	{EV_KEY, KEY_I, "KEY_I"},
+	{EV_KEY, KEY_O, "KEY_O"},
+	{EV_KEY, KEY_P, "KEY_P"},
+	{EV_KEY, KEY_LEFTBRACE, "KEY_LEFTBRACE"},
+	{EV_KEY, KEY_RIGHTBRACE, "KEY_RIGHTBRACE"},
+	{EV_KEY, KEY_ENTER, "KEY_ENTER"},
+	{EV_KEY, KEY_LEFTCTRL, "KEY_LEFTCTRL"},
+	{EV_KEY, KEY_A, "KEY_A"},
+	{EV_KEY, KEY_S, "KEY_S"},
+	{EV_KEY, KEY_D, "KEY_D"},
+	{EV_KEY, KEY_F, "KEY_F"},
+	{EV_KEY, KEY_G, "KEY_G"},
+	{EV_KEY, KEY_H, "KEY_H"},
+	{EV_KEY, KEY_J, "KEY_J"},
+	{EV_KEY, KEY_K, "KEY_K"},
+	{EV_KEY, KEY_L, "KEY_L"},
+	{EV_KEY, KEY_SEMICOLON, "KEY_SEMICOLON"},
+	{EV_KEY, KEY_APOSTROPHE, "KEY_APOSTROPHE"},
+	{EV_KEY, KEY_GRAVE, "KEY_GRAVE"},
+	{EV_KEY, KEY_LEFTSHIFT, "KEY_LEFTSHIFT"},
+	{EV_KEY, KEY_BACKSLASH, "KEY_BACKSLASH"},
+	{EV_KEY, KEY_Z, "KEY_Z"},
+	{EV_KEY, KEY_X, "KEY_X"},
+	{EV_KEY, KEY_C, "KEY_C"},
+	{EV_KEY, KEY_V, "KEY_V"},
+	{EV_KEY, KEY_B, "KEY_B"},
+	{EV_KEY, KEY_N, "KEY_N"},
+	{EV_KEY, KEY_M, "KEY_M"},
+	{EV_KEY, KEY_COMMA, "KEY_COMMA"},
+	{EV_KEY, KEY_DOT, "KEY_DOT"},
+	{EV_KEY, KEY_SLASH, "KEY_SLASH"},
+	{EV_KEY, KEY_RIGHTSHIFT, "KEY_RIGHTSHIFT"},
+	{EV_KEY, KEY_KPASTERISK, "KEY_KPASTERISK"},
+	{EV_KEY, KEY_LEFTALT, "KEY_LEFTALT"},
+	{EV_KEY, KEY_SPACE, "KEY_SPACE"},
+	{EV_KEY, KEY_CAPSLOCK, "KEY_CAPSLOCK"},
+	{EV_KEY, KEY_F1, "KEY_F1"},
+	{EV_KEY, KEY_F2, "KEY_F2"},
+	{EV_KEY, KEY_F3, "KEY_F3"},
+	{EV_KEY, KEY_F4, "KEY_F4"},
+	{EV_KEY, KEY_F5, "KEY_F5"},
+	{EV_KEY, KEY_F6, "KEY_F6"},
+	{EV_KEY, KEY_F7, "KEY_F7"},
+	{EV_KEY, KEY_F8, "KEY_F8"},
+	{EV_KEY, KEY_F9, "KEY_F9"},
+	{EV_KEY, KEY_F10, "KEY_F10"},
+	{EV_KEY, KEY_NUMLOCK, "KEY_NUMLOCK"},
+	{EV_KEY, KEY_SCROLLLOCK, "KEY_SCROLLLOCK"},
+	{EV_KEY, KEY_KP7, "KEY_KP7"},
+	{EV_KEY, KEY_KP8, "KEY_KP8"},
+	{EV_KEY, KEY_KP9, "KEY_KP9"},
+	{EV_KEY, KEY_KPMINUS, "KEY_KPMINUS"},
+	{EV_KEY, KEY_KP4, "KEY_KP4"},
+	{EV_KEY, KEY_KP5, "KEY_KP5"},
+	{EV_KEY, KEY_KP6, "KEY_KP6"},
+	{EV_KEY, KEY_KPPLUS, "KEY_KPPLUS"},
+	{EV_KEY, KEY_KP1, "KEY_KP1"},
+	{EV_KEY, KEY_KP2, "KEY_KP2"},
+	{EV_KEY, KEY_KP3, "KEY_KP3"},
+	{EV_KEY, KEY_KP0, "KEY_KP0"},
+	{EV_KEY, KEY_KPDOT, "KEY_KPDOT"},
+	{EV_KEY, KEY_ZENKAKUHANKAKU, "KEY_ZENKAKUHANKAKU"},
+	{EV_KEY, KEY_102ND, "KEY_102ND"},
+	{EV_KEY, KEY_F11, "KEY_F11"},
+	{EV_KEY, KEY_F12, "KEY_F12"},
+	{EV_KEY, KEY_RO, "KEY_RO"},
+	{EV_KEY, KEY_KATAKANA, "KEY_KATAKANA"},
+	{EV_KEY, KEY_HIRAGANA, "KEY_HIRAGANA"},
+	{EV_KEY, KEY_HENKAN, "KEY_HENKAN"},
+	{EV_KEY, KEY_KATAKANAHIRAGANA, "KEY_KATAKANAHIRAGANA"},
+	{EV_KEY, KEY_MUHENKAN, "KEY_MUHENKAN"},
+	{EV_KEY, KEY_KPJPCOMMA, "KEY_KPJPCOMMA"},
+	{EV_KEY, KEY_KPENTER, "KEY_KPENTER"},
+	{EV_KEY, KEY_RIGHTCTRL, "KEY_RIGHTCTRL"},
+	{EV_KEY, KEY_KPSLASH, "KEY_KPSLASH"},
+	{EV_KEY, KEY_SYSRQ, "KEY_SYSRQ"},
+	{EV_KEY, KEY_RIGHTALT, "KEY_RIGHTALT"},
+	{EV_KEY, KEY_LINEFEED, "KEY_LINEFEED"},
+	{EV_KEY, KEY_HOME, "KEY_HOME"},
+	{EV_KEY, KEY_UP, "KEY_UP"},
+	{EV_KEY, KEY_PAGEUP, "KEY_PAGEUP"},
+	{EV_KEY, KEY_LEFT, "KEY_LEFT"},
+	{EV_KEY, KEY_RIGHT, "KEY_RIGHT"},
+	{EV_KEY, KEY_END, "KEY_END"},
+	{EV_KEY, KEY_DOWN, "KEY_DOWN"},
+	{EV_KEY, KEY_PAGEDOWN, "KEY_PAGEDOWN"},
+	{EV_KEY, KEY_INSERT, "KEY_INSERT"},
+	{EV_KEY, KEY_DELETE, "KEY_DELETE"},
+	{EV_KEY, KEY_MACRO, "KEY_MACRO"},
+	{EV_KEY, KEY_MUTE, "KEY_MUTE"},
+	{EV_KEY, KEY_VOLUMEDOWN, "KEY_VOLUMEDOWN"},
+	{EV_KEY, KEY_VOLUMEUP, "KEY_VOLUMEUP"},
+	{EV_KEY, KEY_POWER, "KEY_POWER"},
+	{EV_KEY, KEY_KPEQUAL, "KEY_KPEQUAL"},
+	{EV_KEY, KEY_KPPLUSMINUS, "KEY_KPPLUSMINUS"},
+	{EV_KEY, KEY_PAUSE, "KEY_PAUSE"},
+	{EV_KEY, KEY_SCALE, "KEY_SCALE"},
+	{EV_KEY, KEY_KPCOMMA, "KEY_KPCOMMA"},
+	{EV_KEY, KEY_HANGEUL, "KEY_HANGEUL"},
+	{EV_KEY, KEY_HANJA, "KEY_HANJA"},
+	{EV_KEY, KEY_YEN, "KEY_YEN"},
+	{EV_KEY, KEY_LEFTMETA, "KEY_LEFTMETA"},
+	{EV_KEY, KEY_RIGHTMETA, "KEY_RIGHTMETA"},
+	{EV_KEY, KEY_COMPOSE, "KEY_COMPOSE"},
+	{EV_KEY, KEY_STOP, "KEY_STOP"},
+	{EV_KEY, KEY_AGAIN, "KEY_AGAIN"},
+	{EV_KEY, KEY_PROPS, "KEY_PROPS"},
+	{EV_KEY, KEY_UNDO, "KEY_UNDO"},
+	{EV_KEY, KEY_FRONT, "KEY_FRONT"},
+	{EV_KEY, KEY_COPY, "KEY_COPY"},
+	{EV_KEY, KEY_OPEN, "KEY_OPEN"},
+	{EV_KEY, KEY_PASTE, "KEY_PASTE"},
+	{EV_KEY, KEY_FIND, "KEY_FIND"},
+	{EV_KEY, KEY_CUT, "KEY_CUT"},
+	{EV_KEY, KEY_HELP, "KEY_HELP"},
+	{EV_KEY, KEY_MENU, "KEY_MENU"},
+	{EV_KEY, KEY_CALC, "KEY_CALC"},
+	{EV_KEY, KEY_SETUP, "KEY_SETUP"},
+	{EV_KEY, KEY_SLEEP, "KEY_SLEEP"},
+	{EV_KEY, KEY_WAKEUP, "KEY_WAKEUP"},
+	{EV_KEY, KEY_FILE, "KEY_FILE"},
+	{EV_KEY, KEY_SENDFILE, "KEY_SENDFILE"},
+	{EV_KEY, KEY_DELETEFILE, "KEY_DELETEFILE"},
+	{EV_KEY, KEY_XFER, "KEY_XFER"},
+	{EV_KEY, KEY_PROG1, "KEY_PROG1"},
+	{EV_KEY, KEY_PROG2, "KEY_PROG2"},
+	{EV_KEY, KEY_WWW, "KEY_WWW"},
+	{EV_KEY, KEY_MSDOS, "KEY_MSDOS"},
+	{EV_KEY, KEY_COFFEE, "KEY_COFFEE"},
+	{EV_KEY, KEY_ROTATE_DISPLAY, "KEY_ROTATE_DISPLAY"},
+	{EV_KEY, KEY_CYCLEWINDOWS, "KEY_CYCLEWINDOWS"},
+	{EV_KEY, KEY_MAIL, "KEY_MAIL"},
+	{EV_KEY, KEY_BOOKMARKS, "KEY_BOOKMARKS"},
+	{EV_KEY, KEY_COMPUTER, "KEY_COMPUTER"},
+	{EV_KEY, KEY_BACK, "KEY_BACK"},
+	{EV_KEY, KEY_FORWARD, "KEY_FORWARD"},
+	{EV_KEY, KEY_CLOSECD, "KEY_CLOSECD"},
+	{EV_KEY, KEY_EJECTCD, "KEY_EJECTCD"},
+	{EV_KEY, KEY_EJECTCLOSECD, "KEY_EJECTCLOSECD"},
+	{EV_KEY, KEY_NEXTSONG, "KEY_NEXTSONG"},
+	{EV_KEY, KEY_PLAYPAUSE, "KEY_PLAYPAUSE"},
+	{EV_KEY, KEY_PREVIOUSSONG, "KEY_PREVIOUSSONG"},
+	{EV_KEY, KEY_STOPCD, "KEY_STOPCD"},
+	{EV_KEY, KEY_RECORD, "KEY_RECORD"},
+	{EV_KEY, KEY_REWIND, "KEY_REWIND"},
+	{EV_KEY, KEY_PHONE, "KEY_PHONE"},
+	{EV_KEY, KEY_ISO, "KEY_ISO"},
+	{EV_KEY, KEY_CONFIG, "KEY_CONFIG"},
+	{EV_KEY, KEY_HOMEPAGE, "KEY_HOMEPAGE"},
+	{EV_KEY, KEY_REFRESH, "KEY_REFRESH"},
+	{EV_KEY, KEY_EXIT, "KEY_EXIT"},
+	{EV_KEY, KEY_MOVE, "KEY_MOVE"},
+	{EV_KEY, KEY_EDIT, "KEY_EDIT"},
+	{EV_KEY, KEY_SCROLLUP, "KEY_SCROLLUP"},
+	{EV_KEY, KEY_SCROLLDOWN, "KEY_SCROLLDOWN"},
+	{EV_KEY, KEY_KPLEFTPAREN, "KEY_KPLEFTPAREN"},
+	{EV_KEY, KEY_KPRIGHTPAREN, "KEY_KPRIGHTPAREN"},
+	{EV_KEY, KEY_NEW, "KEY_NEW"},
+	{EV_KEY, KEY_REDO, "KEY_REDO"},
+	{EV_KEY, KEY_F13, "KEY_F13"},
+	{EV_KEY, KEY_F14, "KEY_F14"},
+	{EV_KEY, KEY_F15, "KEY_F15"},
+	{EV_KEY, KEY_F16, "KEY_F16"},
+	{EV_KEY, KEY_F17, "KEY_F17"},
+	{EV_KEY, KEY_F18, "KEY_F18"},
+	{EV_KEY, KEY_F19, "KEY_F19"},
+	{EV_KEY, KEY_F20, "KEY_F20"},
+	{EV_KEY, KEY_F21, "KEY_F21"},
+	{EV_KEY, KEY_F22, "KEY_F22"},
+	{EV_KEY, KEY_F23, "KEY_F23"},
+	{EV_KEY, KEY_F24, "KEY_F24"},
+	{EV_KEY, KEY_PLAYCD, "KEY_PLAYCD"},
+	{EV_KEY, KEY_PAUSECD, "KEY_PAUSECD"},
+	{EV_KEY, KEY_PROG3, "KEY_PROG3"},
+	{EV_KEY, KEY_PROG4, "KEY_PROG4"},
+	{EV_KEY, KEY_DASHBOARD, "KEY_DASHBOARD"},
+	{EV_KEY, KEY_SUSPEND, "KEY_SUSPEND"},
+	{EV_KEY, KEY_CLOSE, "KEY_CLOSE"},
+	{EV_KEY, KEY_PLAY, "KEY_PLAY"},
+	{EV_KEY, KEY_FASTFORWARD, "KEY_FASTFORWARD"},
+	{EV_KEY, KEY_BASSBOOST, "KEY_BASSBOOST"},
+	{EV_KEY, KEY_PRINT, "KEY_PRINT"},
+	{EV_KEY, KEY_HP, "KEY_HP"},
+	{EV_KEY, KEY_CAMERA, "KEY_CAMERA"},
+	{EV_KEY, KEY_SOUND, "KEY_SOUND"},
+	{EV_KEY, KEY_QUESTION, "KEY_QUESTION"},
+	{EV_KEY, KEY_EMAIL, "KEY_EMAIL"},
+	{EV_KEY, KEY_CHAT, "KEY_CHAT"},
+	{EV_KEY, KEY_SEARCH, "KEY_SEARCH"},
+	{EV_KEY, KEY_CONNECT, "KEY_CONNECT"},
+	{EV_KEY, KEY_FINANCE, "KEY_FINANCE"},
+	{EV_KEY, KEY_SPORT, "KEY_SPORT"},
+	{EV_KEY, KEY_SHOP, "KEY_SHOP"},
+	{EV_KEY, KEY_ALTERASE, "KEY_ALTERASE"},
+	{EV_KEY, KEY_CANCEL, "KEY_CANCEL"},
+	{EV_KEY, KEY_BRIGHTNESSDOWN, "KEY_BRIGHTNESSDOWN"},
+	{EV_KEY, KEY_BRIGHTNESSUP, "KEY_BRIGHTNESSUP"},
+	{EV_KEY, KEY_MEDIA, "KEY_MEDIA"},
+	{EV_KEY, KEY_SWITCHVIDEOMODE, "KEY_SWITCHVIDEOMODE"},
+	{EV_KEY, KEY_KBDILLUMTOGGLE, "KEY_KBDILLUMTOGGLE"},
+	{EV_KEY, KEY_KBDILLUMDOWN, "KEY_KBDILLUMDOWN"},
+	{EV_KEY, KEY_KBDILLUMUP, "KEY_KBDILLUMUP"},
+	{EV_KEY, KEY_SEND, "KEY_SEND"},
+	{EV_KEY, KEY_REPLY, "KEY_REPLY"},
+	{EV_KEY, KEY_FORWARDMAIL, "KEY_FORWARDMAIL"},
+	{EV_KEY, KEY_SAVE, "KEY_SAVE"},
+	{EV_KEY, KEY_DOCUMENTS, "KEY_DOCUMENTS"},
+	{EV_KEY, KEY_BATTERY, "KEY_BATTERY"},
+	{EV_KEY, KEY_BLUETOOTH, "KEY_BLUETOOTH"},
+	{EV_KEY, KEY_WLAN, "KEY_WLAN"},
+	{EV_KEY, KEY_UWB, "KEY_UWB"},
+	{EV_KEY, KEY_UNKNOWN, "KEY_UNKNOWN"},
+	{EV_KEY, KEY_VIDEO_NEXT, "KEY_VIDEO_NEXT"},
+	{EV_KEY, KEY_VIDEO_PREV, "KEY_VIDEO_PREV"},
+	{EV_KEY, KEY_BRIGHTNESS_CYCLE, "KEY_BRIGHTNESS_CYCLE"},
+	{EV_KEY, KEY_BRIGHTNESS_AUTO, "KEY_BRIGHTNESS_AUTO"},
+	{EV_KEY, KEY_DISPLAY_OFF, "KEY_DISPLAY_OFF"},
+	{EV_KEY, KEY_WWAN, "KEY_WWAN"},
+	{EV_KEY, KEY_RFKILL, "KEY_RFKILL"},
+	{EV_KEY, KEY_MICMUTE, "KEY_MICMUTE"},
+	{EV_KEY, KEY_OK, "KEY_OK"},
+	{EV_KEY, KEY_SELECT, "KEY_SELECT"},
+	{EV_KEY, KEY_GOTO, "KEY_GOTO"},
+	{EV_KEY, KEY_CLEAR, "KEY_CLEAR"},
+	{EV_KEY, KEY_POWER2, "KEY_POWER2"},
+	{EV_KEY, KEY_OPTION, "KEY_OPTION"},
+	{EV_KEY, KEY_INFO, "KEY_INFO"},
+	{EV_KEY, KEY_TIME, "KEY_TIME"},
+	{EV_KEY, KEY_VENDOR, "KEY_VENDOR"},
+	{EV_KEY, KEY_ARCHIVE, "KEY_ARCHIVE"},
+	{EV_KEY, KEY_PROGRAM, "KEY_PROGRAM"},
+	{EV_KEY, KEY_CHANNEL, "KEY_CHANNEL"},
+	{EV_KEY, KEY_FAVORITES, "KEY_FAVORITES"},
+	{EV_KEY, KEY_EPG, "KEY_EPG"},
+	{EV_KEY, KEY_PVR, "KEY_PVR"},
+	{EV_KEY, KEY_MHP, "KEY_MHP"},
+	{EV_KEY, KEY_LANGUAGE, "KEY_LANGUAGE"},
+	{EV_KEY, KEY_TITLE, "KEY_TITLE"},
+	{EV_KEY, KEY_SUBTITLE, "KEY_SUBTITLE"},
+	{EV_KEY, KEY_ANGLE, "KEY_ANGLE"},
+	{EV_KEY, KEY_ZOOM, "KEY_ZOOM"},
+	{EV_KEY, KEY_MODE, "KEY_MODE"},
+	{EV_KEY, KEY_KEYBOARD, "KEY_KEYBOARD"},
+	{EV_KEY, KEY_SCREEN, "KEY_SCREEN"},
+	{EV_KEY, KEY_PC, "KEY_PC"},
+	{EV_KEY, KEY_TV, "KEY_TV"},
+	{EV_KEY, KEY_TV2, "KEY_TV2"},
+	{EV_KEY, KEY_VCR, "KEY_VCR"},
+	{EV_KEY, KEY_VCR2, "KEY_VCR2"},
+	{EV_KEY, KEY_SAT, "KEY_SAT"},
+	{EV_KEY, KEY_SAT2, "KEY_SAT2"},
+	{EV_KEY, KEY_CD, "KEY_CD"},
+	{EV_KEY, KEY_TAPE, "KEY_TAPE"},
+	{EV_KEY, KEY_RADIO, "KEY_RADIO"},
+	{EV_KEY, KEY_TUNER, "KEY_TUNER"},
+	{EV_KEY, KEY_PLAYER, "KEY_PLAYER"},
+	{EV_KEY, KEY_TEXT, "KEY_TEXT"},
+	{EV_KEY, KEY_DVD, "KEY_DVD"},
+	{EV_KEY, KEY_AUX, "KEY_AUX"},
+	{EV_KEY, KEY_MP3, "KEY_MP3"},
+	{EV_KEY, KEY_AUDIO, "KEY_AUDIO"},
+	{EV_KEY, KEY_VIDEO, "KEY_VIDEO"},
+	{EV_KEY, KEY_DIRECTORY, "KEY_DIRECTORY"},
+	{EV_KEY, KEY_LIST, "KEY_LIST"},
+	{EV_KEY, KEY_MEMO, "KEY_MEMO"},
+	{EV_KEY, KEY_CALENDAR, "KEY_CALENDAR"},
+	{EV_KEY, KEY_RED, "KEY_RED"},
+	{EV_KEY, KEY_GREEN, "KEY_GREEN"},
+	{EV_KEY, KEY_YELLOW, "KEY_YELLOW"},
+	{EV_KEY, KEY_BLUE, "KEY_BLUE"},
+	{EV_KEY, KEY_CHANNELUP, "KEY_CHANNELUP"},
+	{EV_KEY, KEY_CHANNELDOWN, "KEY_CHANNELDOWN"},
+	{EV_KEY, KEY_FIRST, "KEY_FIRST"},
+	{EV_KEY, KEY_LAST, "KEY_LAST"},
+	{EV_KEY, KEY_AB, "KEY_AB"},
+	{EV_KEY, KEY_NEXT, "KEY_NEXT"},
+	{EV_KEY, KEY_RESTART, "KEY_RESTART"},
+	{EV_KEY, KEY_SLOW, "KEY_SLOW"},
+	{EV_KEY, KEY_SHUFFLE, "KEY_SHUFFLE"},
+	{EV_KEY, KEY_BREAK, "KEY_BREAK"},
+	{EV_KEY, KEY_PREVIOUS, "KEY_PREVIOUS"},
+	{EV_KEY, KEY_DIGITS, "KEY_DIGITS"},
+	{EV_KEY, KEY_TEEN, "KEY_TEEN"},
+	{EV_KEY, KEY_TWEN, "KEY_TWEN"},
+	{EV_KEY, KEY_VIDEOPHONE, "KEY_VIDEOPHONE"},
+	{EV_KEY, KEY_GAMES, "KEY_GAMES"},
+	{EV_KEY, KEY_ZOOMIN, "KEY_ZOOMIN"},
+	{EV_KEY, KEY_ZOOMOUT, "KEY_ZOOMOUT"},
+	{EV_KEY, KEY_ZOOMRESET, "KEY_ZOOMRESET"},
+	{EV_KEY, KEY_WORDPROCESSOR, "KEY_WORDPROCESSOR"},
+	{EV_KEY, KEY_EDITOR, "KEY_EDITOR"},
+	{EV_KEY, KEY_SPREADSHEET, "KEY_SPREADSHEET"},
+	{EV_KEY, KEY_GRAPHICSEDITOR, "KEY_GRAPHICSEDITOR"},
+	{EV_KEY, KEY_PRESENTATION, "KEY_PRESENTATION"},
+	{EV_KEY, KEY_DATABASE, "KEY_DATABASE"},
+	{EV_KEY, KEY_NEWS, "KEY_NEWS"},
+	{EV_KEY, KEY_VOICEMAIL, "KEY_VOICEMAIL"},
+	{EV_KEY, KEY_ADDRESSBOOK, "KEY_ADDRESSBOOK"},
+	{EV_KEY, KEY_MESSENGER, "KEY_MESSENGER"},
+	{EV_KEY, KEY_DISPLAYTOGGLE, "KEY_DISPLAYTOGGLE"},
+	{EV_KEY, KEY_SPELLCHECK, "KEY_SPELLCHECK"},
+	{EV_KEY, KEY_LOGOFF, "KEY_LOGOFF"},
+	{EV_KEY, KEY_DOLLAR, "KEY_DOLLAR"},
+	{EV_KEY, KEY_EURO, "KEY_EURO"},
+	{EV_KEY, KEY_FRAMEBACK, "KEY_FRAMEBACK"},
+	{EV_KEY, KEY_FRAMEFORWARD, "KEY_FRAMEFORWARD"},
+	{EV_KEY, KEY_CONTEXT_MENU, "KEY_CONTEXT_MENU"},
+	{EV_KEY, KEY_MEDIA_REPEAT, "KEY_MEDIA_REPEAT"},
+	{EV_KEY, KEY_10CHANNELSUP, "KEY_10CHANNELSUP"},
+	{EV_KEY, KEY_10CHANNELSDOWN, "KEY_10CHANNELSDOWN"},
+	{EV_KEY, KEY_IMAGES, "KEY_IMAGES"},
+	{EV_KEY, KEY_DEL_EOL, "KEY_DEL_EOL"},
+	{EV_KEY, KEY_DEL_EOS, "KEY_DEL_EOS"},
+	{EV_KEY, KEY_INS_LINE, "KEY_INS_LINE"},
+	{EV_KEY, KEY_DEL_LINE, "KEY_DEL_LINE"},
+	{EV_KEY, KEY_FN, "KEY_FN"},
+	{EV_KEY, KEY_FN_ESC, "KEY_FN_ESC"},
+	{EV_KEY, KEY_FN_F1, "KEY_FN_F1"},
+	{EV_KEY, KEY_FN_F2, "KEY_FN_F2"},
+	{EV_KEY, KEY_FN_F3, "KEY_FN_F3"},
+	{EV_KEY, KEY_FN_F4, "KEY_FN_F4"},
+	{EV_KEY, KEY_FN_F5, "KEY_FN_F5"},
+	{EV_KEY, KEY_FN_F6, "KEY_FN_F6"},
+	{EV_KEY, KEY_FN_F7, "KEY_FN_F7"},
+	{EV_KEY, KEY_FN_F8, "KEY_FN_F8"},
+	{EV_KEY, KEY_FN_F9, "KEY_FN_F9"},
+	{EV_KEY, KEY_FN_F10, "KEY_FN_F10"},
+	{EV_KEY, KEY_FN_F11, "KEY_FN_F11"},
+	{EV_KEY, KEY_FN_F12, "KEY_FN_F12"},
+	{EV_KEY, KEY_FN_1, "KEY_FN_1"},
+	{EV_KEY, KEY_FN_2, "KEY_FN_2"},
+	{EV_KEY, KEY_FN_D, "KEY_FN_D"},
+	{EV_KEY, KEY_FN_E, "KEY_FN_E"},
+	{EV_KEY, KEY_FN_F, "KEY_FN_F"},
+	{EV_KEY, KEY_FN_S, "KEY_FN_S"},
+	{EV_KEY, KEY_FN_B, "KEY_FN_B"},
+	{EV_KEY, KEY_BRL_DOT1, "KEY_BRL_DOT1"},
+	{EV_KEY, KEY_BRL_DOT2, "KEY_BRL_DOT2"},
+	{EV_KEY, KEY_BRL_DOT3, "KEY_BRL_DOT3"},
+	{EV_KEY, KEY_BRL_DOT4, "KEY_BRL_DOT4"},
+	{EV_KEY, KEY_BRL_DOT5, "KEY_BRL_DOT5"},
+	{EV_KEY, KEY_BRL_DOT6, "KEY_BRL_DOT6"},
+	{EV_KEY, KEY_BRL_DOT7, "KEY_BRL_DOT7"},
+	{EV_KEY, KEY_BRL_DOT8, "KEY_BRL_DOT8"},
+	{EV_KEY, KEY_BRL_DOT9, "KEY_BRL_DOT9"},
+	{EV_KEY, KEY_BRL_DOT10, "KEY_BRL_DOT10"},
+	{EV_KEY, KEY_NUMERIC_0, "KEY_NUMERIC_0"},
+	{EV_KEY, KEY_NUMERIC_1, "KEY_NUMERIC_1"},
+	{EV_KEY, KEY_NUMERIC_2, "KEY_NUMERIC_2"},
+	{EV_KEY, KEY_NUMERIC_3, "KEY_NUMERIC_3"},
+	{EV_KEY, KEY_NUMERIC_4, "KEY_NUMERIC_4"},
+	{EV_KEY, KEY_NUMERIC_5, "KEY_NUMERIC_5"},
+	{EV_KEY, KEY_NUMERIC_6, "KEY_NUMERIC_6"},
+	{EV_KEY, KEY_NUMERIC_7, "KEY_NUMERIC_7"},
+	{EV_KEY, KEY_NUMERIC_8, "KEY_NUMERIC_8"},
+	{EV_KEY, KEY_NUMERIC_9, "KEY_NUMERIC_9"},
+	{EV_KEY, KEY_NUMERIC_STAR, "KEY_NUMERIC_STAR"},
+	{EV_KEY, KEY_NUMERIC_POUND, "KEY_NUMERIC_POUND"},
+	{EV_KEY, KEY_NUMERIC_A, "KEY_NUMERIC_A"},
+	{EV_KEY, KEY_NUMERIC_B, "KEY_NUMERIC_B"},
+	{EV_KEY, KEY_NUMERIC_C, "KEY_NUMERIC_C"},
+	{EV_KEY, KEY_NUMERIC_D, "KEY_NUMERIC_D"},
+	{EV_KEY, KEY_CAMERA_FOCUS, "KEY_CAMERA_FOCUS"},
+	{EV_KEY, KEY_WPS_BUTTON, "KEY_WPS_BUTTON"},
+	{EV_KEY, KEY_TOUCHPAD_TOGGLE, "KEY_TOUCHPAD_TOGGLE"},
+	{EV_KEY, KEY_TOUCHPAD_ON, "KEY_TOUCHPAD_ON"},
+	{EV_KEY, KEY_TOUCHPAD_OFF, "KEY_TOUCHPAD_OFF"},
+	{EV_KEY, KEY_CAMERA_ZOOMIN, "KEY_CAMERA_ZOOMIN"},
+	{EV_KEY, KEY_CAMERA_ZOOMOUT, "KEY_CAMERA_ZOOMOUT"},
+	{EV_KEY, KEY_CAMERA_UP, "KEY_CAMERA_UP"},
+	{EV_KEY, KEY_CAMERA_DOWN, "KEY_CAMERA_DOWN"},
+	{EV_KEY, KEY_CAMERA_LEFT, "KEY_CAMERA_LEFT"},
+	{EV_KEY, KEY_CAMERA_RIGHT, "KEY_CAMERA_RIGHT"},
+	{EV_KEY, KEY_ATTENDANT_ON, "KEY_ATTENDANT_ON"},
+	{EV_KEY, KEY_ATTENDANT_OFF, "KEY_ATTENDANT_OFF"},
+	{EV_KEY, KEY_ATTENDANT_TOGGLE, "KEY_ATTENDANT_TOGGLE"},
+	{EV_KEY, KEY_LIGHTS_TOGGLE, "KEY_LIGHTS_TOGGLE"},
+	{EV_KEY, KEY_ALS_TOGGLE, "KEY_ALS_TOGGLE"},
+	{EV_KEY, KEY_BUTTONCONFIG, "KEY_BUTTONCONFIG"},
+	{EV_KEY, KEY_TASKMANAGER, "KEY_TASKMANAGER"},
+	{EV_KEY, KEY_JOURNAL, "KEY_JOURNAL"},
+	{EV_KEY, KEY_CONTROLPANEL, "KEY_CONTROLPANEL"},
+	{EV_KEY, KEY_APPSELECT, "KEY_APPSELECT"},
+	{EV_KEY, KEY_SCREENSAVER, "KEY_SCREENSAVER"},
+	{EV_KEY, KEY_VOICECOMMAND, "KEY_VOICECOMMAND"},
+	{EV_KEY, KEY_ASSISTANT, "KEY_ASSISTANT"},
+	{EV_KEY, KEY_BRIGHTNESS_MIN, "KEY_BRIGHTNESS_MIN"},
+	{EV_KEY, KEY_BRIGHTNESS_MAX, "KEY_BRIGHTNESS_MAX"},
+	{EV_KEY, KEY_KBDINPUTASSIST_PREV, "KEY_KBDINPUTASSIST_PREV"},
+	{EV_KEY, KEY_KBDINPUTASSIST_NEXT, "KEY_KBDINPUTASSIST_NEXT"},
+	{EV_KEY, KEY_KBDINPUTASSIST_PREVGROUP, "KEY_KBDINPUTASSIST_PREVGROUP"},
+	{EV_KEY, KEY_KBDINPUTASSIST_NEXTGROUP, "KEY_KBDINPUTASSIST_NEXTGROUP"},
+	{EV_KEY, KEY_KBDINPUTASSIST_ACCEPT, "KEY_KBDINPUTASSIST_ACCEPT"},
+	{EV_KEY, KEY_KBDINPUTASSIST_CANCEL, "KEY_KBDINPUTASSIST_CANCEL"},
+	{EV_KEY, KEY_RIGHT_UP, "KEY_RIGHT_UP"},
+	{EV_KEY, KEY_RIGHT_DOWN, "KEY_RIGHT_DOWN"},
+	{EV_KEY, KEY_LEFT_UP, "KEY_LEFT_UP"},
+	{EV_KEY, KEY_LEFT_DOWN, "KEY_LEFT_DOWN"},
+	{EV_KEY, KEY_ROOT_MENU, "KEY_ROOT_MENU"},
+	{EV_KEY, KEY_MEDIA_TOP_MENU, "KEY_MEDIA_TOP_MENU"},
+	{EV_KEY, KEY_NUMERIC_11, "KEY_NUMERIC_11"},
+	{EV_KEY, KEY_NUMERIC_12, "KEY_NUMERIC_12"},
+	{EV_KEY, KEY_AUDIO_DESC, "KEY_AUDIO_DESC"},
+	{EV_KEY, KEY_3D_MODE, "KEY_3D_MODE"},
+	{EV_KEY, KEY_NEXT_FAVORITE, "KEY_NEXT_FAVORITE"},
+	{EV_KEY, KEY_STOP_RECORD, "KEY_STOP_RECORD"},
+	{EV_KEY, KEY_PAUSE_RECORD, "KEY_PAUSE_RECORD"},
+	{EV_KEY, KEY_VOD, "KEY_VOD"},
+	{EV_KEY, KEY_UNMUTE, "KEY_UNMUTE"},
+	{EV_KEY, KEY_FASTREVERSE, "KEY_FASTREVERSE"},
+	{EV_KEY, KEY_SLOWREVERSE, "KEY_SLOWREVERSE"},
+	{EV_KEY, KEY_DATA, "KEY_DATA"},
+	{EV_KEY, KEY_ONSCREEN_KEYBOARD, "KEY_ONSCREEN_KEYBOARD"},
+	{EV_KEY, BTN_MISC, "BTN_MISC"},
+	{EV_KEY, BTN_0, "BTN_0"},
+	{EV_KEY, BTN_1, "BTN_1"},
+	{EV_KEY, BTN_2, "BTN_2"},
+	{EV_KEY, BTN_3, "BTN_3"},
+	{EV_KEY, BTN_4, "BTN_4"},
+	{EV_KEY, BTN_5, "BTN_5"},
+	{EV_KEY, BTN_6, "BTN_6"},
+	{EV_KEY, BTN_7, "BTN_7"},
+	{EV_KEY, BTN_8, "BTN_8"},
+	{EV_KEY, BTN_9, "BTN_9"},
+	{EV_KEY, BTN_MOUSE, "BTN_MOUSE"},
+	{EV_KEY, BTN_LEFT, "BTN_LEFT"},
+	{EV_KEY, BTN_RIGHT, "BTN_RIGHT"},
+	{EV_KEY, BTN_MIDDLE, "BTN_MIDDLE"},
+	{EV_KEY, BTN_SIDE, "BTN_SIDE"},
+	{EV_KEY, BTN_EXTRA, "BTN_EXTRA"},
+	{EV_KEY, BTN_FORWARD, "BTN_FORWARD"},
+	{EV_KEY, BTN_BACK, "BTN_BACK"},
+	{EV_KEY, BTN_TASK, "BTN_TASK"},
+	{EV_KEY, BTN_JOYSTICK, "BTN_JOYSTICK"},
+	{EV_KEY, BTN_TRIGGER, "BTN_TRIGGER"},
+	{EV_KEY, BTN_THUMB, "BTN_THUMB"},
+	{EV_KEY, BTN_THUMB2, "BTN_THUMB2"},
+	{EV_KEY, BTN_TOP, "BTN_TOP"},
+	{EV_KEY, BTN_TOP2, "BTN_TOP2"},
+	{EV_KEY, BTN_PINKIE, "BTN_PINKIE"},
+	{EV_KEY, BTN_BASE, "BTN_BASE"},
+	{EV_KEY, BTN_BASE2, "BTN_BASE2"},
+	{EV_KEY, BTN_BASE3, "BTN_BASE3"},
+	{EV_KEY, BTN_BASE4, "BTN_BASE4"},
+	{EV_KEY, BTN_BASE5, "BTN_BASE5"},
+	{EV_KEY, BTN_BASE6, "BTN_BASE6"},
+	{EV_KEY, BTN_DEAD, "BTN_DEAD"},
+	{EV_KEY, BTN_GAMEPAD, "BTN_GAMEPAD"},
+	{EV_KEY, BTN_SOUTH, "BTN_SOUTH"},
+	{EV_KEY, BTN_EAST, "BTN_EAST"},
+	{EV_KEY, BTN_C, "BTN_C"},
+	{EV_KEY, BTN_NORTH, "BTN_NORTH"},
+	{EV_KEY, BTN_WEST, "BTN_WEST"},
+	{EV_KEY, BTN_Z, "BTN_Z"},
+	{EV_KEY, BTN_TL, "BTN_TL"},
+	{EV_KEY, BTN_TR, "BTN_TR"},
+	{EV_KEY, BTN_TL2, "BTN_TL2"},
+	{EV_KEY, BTN_TR2, "BTN_TR2"},
+	{EV_KEY, BTN_SELECT, "BTN_SELECT"},
+	{EV_KEY, BTN_START, "BTN_START"},
+	{EV_KEY, BTN_MODE, "BTN_MODE"},
+	{EV_KEY, BTN_THUMBL, "BTN_THUMBL"},
+	{EV_KEY, BTN_THUMBR, "BTN_THUMBR"},
+	{EV_KEY, BTN_DIGI, "BTN_DIGI"},
+	{EV_KEY, BTN_TOOL_PEN, "BTN_TOOL_PEN"},
+	{EV_KEY, BTN_TOOL_RUBBER, "BTN_TOOL_RUBBER"},
+	{EV_KEY, BTN_TOOL_BRUSH, "BTN_TOOL_BRUSH"},
+	{EV_KEY, BTN_TOOL_PENCIL, "BTN_TOOL_PENCIL"},
+	{EV_KEY, BTN_TOOL_AIRBRUSH, "BTN_TOOL_AIRBRUSH"},
+	{EV_KEY, BTN_TOOL_FINGER, "BTN_TOOL_FINGER"},
+	{EV_KEY, BTN_TOOL_MOUSE, "BTN_TOOL_MOUSE"},
+	{EV_KEY, BTN_TOOL_LENS, "BTN_TOOL_LENS"},
+	{EV_KEY, BTN_TOOL_QUINTTAP, "BTN_TOOL_QUINTTAP"},
+	{EV_KEY, BTN_TOUCH, "BTN_TOUCH"},
+	{EV_KEY, BTN_STYLUS, "BTN_STYLUS"},
+	{EV_KEY, BTN_STYLUS2, "BTN_STYLUS2"},
+	{EV_KEY, BTN_TOOL_DOUBLETAP, "BTN_TOOL_DOUBLETAP"},
+	{EV_KEY, BTN_TOOL_TRIPLETAP, "BTN_TOOL_TRIPLETAP"},
+	{EV_KEY, BTN_TOOL_QUADTAP, "BTN_TOOL_QUADTAP"},
+	{EV_KEY, BTN_WHEEL, "BTN_WHEEL"},
+	{EV_KEY, BTN_GEAR_DOWN, "BTN_GEAR_DOWN"},
+	{EV_KEY, BTN_GEAR_UP, "BTN_GEAR_UP"},
+	{EV_KEY, BTN_DPAD_UP, "BTN_DPAD_UP"},
+	{EV_KEY, BTN_DPAD_DOWN, "BTN_DPAD_DOWN"},
+	{EV_KEY, BTN_DPAD_LEFT, "BTN_DPAD_LEFT"},
+	{EV_KEY, BTN_DPAD_RIGHT, "BTN_DPAD_RIGHT"},
+	{EV_KEY, BTN_TRIGGER_HAPPY, "BTN_TRIGGER_HAPPY"},
+	{EV_KEY, BTN_TRIGGER_HAPPY1, "BTN_TRIGGER_HAPPY1"},
+	{EV_KEY, BTN_TRIGGER_HAPPY2, "BTN_TRIGGER_HAPPY2"},
+	{EV_KEY, BTN_TRIGGER_HAPPY3, "BTN_TRIGGER_HAPPY3"},
+	{EV_KEY, BTN_TRIGGER_HAPPY4, "BTN_TRIGGER_HAPPY4"},
+	{EV_KEY, BTN_TRIGGER_HAPPY5, "BTN_TRIGGER_HAPPY5"},
+	{EV_KEY, BTN_TRIGGER_HAPPY6, "BTN_TRIGGER_HAPPY6"},
+	{EV_KEY, BTN_TRIGGER_HAPPY7, "BTN_TRIGGER_HAPPY7"},
+	{EV_KEY, BTN_TRIGGER_HAPPY8, "BTN_TRIGGER_HAPPY8"},
+	{EV_KEY, BTN_TRIGGER_HAPPY9, "BTN_TRIGGER_HAPPY9"},
+	{EV_KEY, BTN_TRIGGER_HAPPY10, "BTN_TRIGGER_HAPPY10"},
+	{EV_KEY, BTN_TRIGGER_HAPPY11, "BTN_TRIGGER_HAPPY11"},
+	{EV_KEY, BTN_TRIGGER_HAPPY12, "BTN_TRIGGER_HAPPY12"},
+	{EV_KEY, BTN_TRIGGER_HAPPY13, "BTN_TRIGGER_HAPPY13"},
+	{EV_KEY, BTN_TRIGGER_HAPPY14, "BTN_TRIGGER_HAPPY14"},
+	{EV_KEY, BTN_TRIGGER_HAPPY15, "BTN_TRIGGER_HAPPY15"},
+	{EV_KEY, BTN_TRIGGER_HAPPY16, "BTN_TRIGGER_HAPPY16"},
+	{EV_KEY, BTN_TRIGGER_HAPPY17, "BTN_TRIGGER_HAPPY17"},
+	{EV_KEY, BTN_TRIGGER_HAPPY18, "BTN_TRIGGER_HAPPY18"},
+	{EV_KEY, BTN_TRIGGER_HAPPY19, "BTN_TRIGGER_HAPPY19"},
+	{EV_KEY, BTN_TRIGGER_HAPPY20, "BTN_TRIGGER_HAPPY20"},
+	{EV_KEY, BTN_TRIGGER_HAPPY21, "BTN_TRIGGER_HAPPY21"},
+	{EV_KEY, BTN_TRIGGER_HAPPY22, "BTN_TRIGGER_HAPPY22"},
+	{EV_KEY, BTN_TRIGGER_HAPPY23, "BTN_TRIGGER_HAPPY23"},
+	{EV_KEY, BTN_TRIGGER_HAPPY24, "BTN_TRIGGER_HAPPY24"},
+	{EV_KEY, BTN_TRIGGER_HAPPY25, "BTN_TRIGGER_HAPPY25"},
+	{EV_KEY, BTN_TRIGGER_HAPPY26, "BTN_TRIGGER_HAPPY26"},
+	{EV_KEY, BTN_TRIGGER_HAPPY27, "BTN_TRIGGER_HAPPY27"},
+	{EV_KEY, BTN_TRIGGER_HAPPY28, "BTN_TRIGGER_HAPPY28"},
+	{EV_KEY, BTN_TRIGGER_HAPPY29, "BTN_TRIGGER_HAPPY29"},
+	{EV_KEY, BTN_TRIGGER_HAPPY30, "BTN_TRIGGER_HAPPY30"},
+	{EV_KEY, BTN_TRIGGER_HAPPY31, "BTN_TRIGGER_HAPPY31"},
+	{EV_KEY, BTN_TRIGGER_HAPPY32, "BTN_TRIGGER_HAPPY32"},
+	{EV_KEY, BTN_TRIGGER_HAPPY33, "BTN_TRIGGER_HAPPY33"},
+	{EV_KEY, BTN_TRIGGER_HAPPY34, "BTN_TRIGGER_HAPPY34"},
+	{EV_KEY, BTN_TRIGGER_HAPPY35, "BTN_TRIGGER_HAPPY35"},
+	{EV_KEY, BTN_TRIGGER_HAPPY36, "BTN_TRIGGER_HAPPY36"},
+	{EV_KEY, BTN_TRIGGER_HAPPY37, "BTN_TRIGGER_HAPPY37"},
+	{EV_KEY, BTN_TRIGGER_HAPPY38, "BTN_TRIGGER_HAPPY38"},
+	{EV_KEY, BTN_TRIGGER_HAPPY39, "BTN_TRIGGER_HAPPY39"},
+	{EV_KEY, BTN_TRIGGER_HAPPY40, "BTN_TRIGGER_HAPPY40"},
+	{EV_REL, REL_X, "REL_X"},
+	{EV_REL, REL_Y, "REL_Y"},
+	{EV_REL, REL_Z, "REL_Z"},
+	{EV_REL, REL_RX, "REL_RX"},
+	{EV_REL, REL_RY, "REL_RY"},
+	{EV_REL, REL_RZ, "REL_RZ"},
+	{EV_REL, REL_HWHEEL, "REL_HWHEEL"},
+	{EV_REL, REL_DIAL, "REL_DIAL"},
+	{EV_REL, REL_WHEEL, "REL_WHEEL"},
+	{EV_REL, REL_MISC, "REL_MISC"},
+	{EV_ABS, ABS_X, "ABS_X"},
+	{EV_ABS, ABS_Y, "ABS_Y"},
+	{EV_ABS, ABS_Z, "ABS_Z"},
+	{EV_ABS, ABS_RX, "ABS_RX"},
+	{EV_ABS, ABS_RY, "ABS_RY"},
+	{EV_ABS, ABS_RZ, "ABS_RZ"},
+	{EV_ABS, ABS_THROTTLE, "ABS_THROTTLE"},
+	{EV_ABS, ABS_RUDDER, "ABS_RUDDER"},
+	{EV_ABS, ABS_WHEEL, "ABS_WHEEL"},
+	{EV_ABS, ABS_GAS, "ABS_GAS"},
+	{EV_ABS, ABS_BRAKE, "ABS_BRAKE"},
+	{EV_ABS, ABS_HAT0X, "ABS_HAT0X"},
+	{EV_ABS, ABS_HAT0Y, "ABS_HAT0Y"},
+	{EV_ABS, ABS_HAT1X, "ABS_HAT1X"},
+	{EV_ABS, ABS_HAT1Y, "ABS_HAT1Y"},
+	{EV_ABS, ABS_HAT2X, "ABS_HAT2X"},
+	{EV_ABS, ABS_HAT2Y, "ABS_HAT2Y"},
+	{EV_ABS, ABS_HAT3X, "ABS_HAT3X"},
+	{EV_ABS, ABS_HAT3Y, "ABS_HAT3Y"},
+	{EV_ABS, ABS_PRESSURE, "ABS_PRESSURE"},
+	{EV_ABS, ABS_DISTANCE, "ABS_DISTANCE"},
+	{EV_ABS, ABS_TILT_X, "ABS_TILT_X"},
+	{EV_ABS, ABS_TILT_Y, "ABS_TILT_Y"},
+	{EV_ABS, ABS_TOOL_WIDTH, "ABS_TOOL_WIDTH"},
+	{EV_ABS, ABS_VOLUME, "ABS_VOLUME"},
+	{EV_ABS, ABS_MISC, "ABS_MISC"},
+	{EV_ABS, ABS_MT_SLOT, "ABS_MT_SLOT"},
+	{EV_ABS, ABS_MT_TOUCH_MAJOR, "ABS_MT_TOUCH_MAJOR"},
+	{EV_ABS, ABS_MT_TOUCH_MINOR, "ABS_MT_TOUCH_MINOR"},
+	{EV_ABS, ABS_MT_WIDTH_MAJOR, "ABS_MT_WIDTH_MAJOR"},
+	{EV_ABS, ABS_MT_WIDTH_MINOR, "ABS_MT_WIDTH_MINOR"},
+	{EV_ABS, ABS_MT_ORIENTATION, "ABS_MT_ORIENTATION"},
+	{EV_ABS, ABS_MT_POSITION_X, "ABS_MT_POSITION_X"},
+	{EV_ABS, ABS_MT_POSITION_Y, "ABS_MT_POSITION_Y"},
+	{EV_ABS, ABS_MT_TOOL_TYPE, "ABS_MT_TOOL_TYPE"},
+	{EV_ABS, ABS_MT_BLOB_ID, "ABS_MT_BLOB_ID"},
+	{EV_ABS, ABS_MT_TRACKING_ID, "ABS_MT_TRACKING_ID"},
+	{EV_ABS, ABS_MT_PRESSURE, "ABS_MT_PRESSURE"},
+	{EV_ABS, ABS_MT_DISTANCE, "ABS_MT_DISTANCE"},
+	{EV_ABS, ABS_MT_TOOL_X, "ABS_MT_TOOL_X"},
+	{EV_ABS, ABS_MT_TOOL_Y, "ABS_MT_TOOL_Y"},
+	{EV_SW, SW_LID, "SW_LID"},
+	{EV_SW, SW_TABLET_MODE, "SW_TABLET_MODE"},
+	{EV_SW, SW_HEADPHONE_INSERT, "SW_HEADPHONE_INSERT"},
+	{EV_SW, SW_RFKILL_ALL, "SW_RFKILL_ALL"},
+	{EV_SW, SW_MICROPHONE_INSERT, "SW_MICROPHONE_INSERT"},
+	{EV_SW, SW_DOCK, "SW_DOCK"},
+	{EV_SW, SW_LINEOUT_INSERT, "SW_LINEOUT_INSERT"},
+	{EV_SW, SW_JACK_PHYSICAL_INSERT, "SW_JACK_PHYSICAL_INSERT"},
+	{EV_SW, SW_VIDEOOUT_INSERT, "SW_VIDEOOUT_INSERT"},
+	{EV_SW, SW_CAMERA_LENS_COVER, "SW_CAMERA_LENS_COVER"},
+	{EV_SW, SW_KEYPAD_SLIDE, "SW_KEYPAD_SLIDE"},
+	{EV_SW, SW_FRONT_PROXIMITY, "SW_FRONT_PROXIMITY"},
+	{EV_SW, SW_ROTATE_LOCK, "SW_ROTATE_LOCK"},
+	{EV_SW, SW_LINEIN_INSERT, "SW_LINEIN_INSERT"},
+	{EV_SW, SW_MUTE_DEVICE, "SW_MUTE_DEVICE"},
+	{EV_SW, SW_PEN_INSERTED, "SW_PEN_INSERTED"},
+	{EV_MSC, MSC_SERIAL, "MSC_SERIAL"},
+	{EV_MSC, MSC_PULSELED, "MSC_PULSELED"},
+	{EV_MSC, MSC_GESTURE, "MSC_GESTURE"},
+	{EV_MSC, MSC_RAW, "MSC_RAW"},
+	{EV_MSC, MSC_SCAN, "MSC_SCAN"},
+	{EV_MSC, MSC_TIMESTAMP, "MSC_TIMESTAMP"},
+	{EV_LED, LED_NUML, "LED_NUML"},
+	{EV_LED, LED_CAPSL, "LED_CAPSL"},
+	{EV_LED, LED_SCROLLL, "LED_SCROLLL"},
+	{EV_LED, LED_COMPOSE, "LED_COMPOSE"},
+	{EV_LED, LED_KANA, "LED_KANA"},
+	{EV_LED, LED_SLEEP, "LED_SLEEP"},
+	{EV_LED, LED_SUSPEND, "LED_SUSPEND"},
+	{EV_LED, LED_MUTE, "LED_MUTE"},
+	{EV_LED, LED_MISC, "LED_MISC"},
+	{EV_LED, LED_MAIL, "LED_MAIL"},
+	{EV_LED, LED_CHARGING, "LED_CHARGING"},
+	{EV_SND, SND_CLICK, "SND_CLICK"},
+	{EV_SND, SND_BELL, "SND_BELL"},
+	{EV_SND, SND_TONE, "SND_TONE"},
+	{EV_REP, REP_DELAY, "REP_DELAY"},
+	{EV_REP, REP_PERIOD, "REP_PERIOD"},
+}
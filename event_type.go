@@ -51,6 +51,25 @@ const (
 	forceFeedbackStatusEvent = EV_FF_STATUS
 )
 
+// maxEventCodes holds the highest code of each event type (the _MAX values of
+// input-event-codes.h), sizing the capability bitmasks; the EV_SYN entry is
+// the highest event type.
+var maxEventCodes = map[EventType]int{
+	EV_SYN: 0x1f,
+	EV_KEY: 0x2ff,
+	EV_REL: 0x0f,
+	EV_ABS: 0x3f,
+	EV_MSC: 0x07,
+	EV_SW:  0x10,
+	EV_LED: 0x0f,
+	EV_SND: 0x07,
+	EV_REP: 0x01,
+	EV_FF:  0x7f,
+}
+
+// maxDeviceProperty is INPUT_PROP_MAX.
+const maxDeviceProperty = 0x1f
+
 func MarshalEventType(eventType int) EventType {
 	switch uint16(eventType) {
 	case EV_SYN.Code():
@@ -73,6 +92,8 @@ func MarshalEventType(eventType int) EventType {
 		return EV_REP
 	case EV_FF.Code():
 		return EV_FF
+	case EV_PWR.Code():
+		return EV_PWR
 	case EV_FF_STATUS.Code():
 		return EV_FF_STATUS
 	default: // Invalid
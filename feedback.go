@@ -0,0 +1,195 @@
+package uinput
+
+import (
+	"fmt"
+	"os"
+)
+
+// LEDState is the keyboard LED state most recently written back to a virtual
+// keyboard by the kernel, which mirrors the lock state of whichever consumer
+// (console, X server, compositor) currently owns the keyboard.
+type LEDState struct {
+	CapsLock   bool
+	NumLock    bool
+	ScrollLock bool
+	Compose    bool
+	Kana       bool
+}
+
+var feedbackLEDs = []EventCode{LED_NUML, LED_CAPSL, LED_SCROLLL, LED_COMPOSE, LED_KANA}
+
+// RegisterFeedback declares the LEDs and the bell, so consumers write their
+// state back into the uinput file descriptor where readFeedback picks it up.
+func (self *Device) RegisterFeedback() error {
+	if err := self.newEventSource(EV_LED); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register led events: %v", err)
+	}
+	for _, led := range feedbackLEDs {
+		if err := ioctl(self.FD, LEDBit.Code(), uintptr(led)); err != nil {
+			self.FD.Close()
+			return fmt.Errorf("[error] failed to register led %d: %v", led, err)
+		}
+	}
+	if err := self.newEventSource(EV_SND); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register sound events: %v", err)
+	}
+	if err := ioctl(self.FD, SoundBit.Code(), uintptr(SND_BELL)); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register bell: %v", err)
+	}
+	return nil
+}
+
+// readFeedback decodes the events the kernel writes back into the uinput file
+// descriptor until it is closed by Disconnect, then closes the LED and bell
+// change channels. Force feedback requests are answered as they come.
+func (self *Device) readFeedback(fd *os.File) {
+	if self.Keyboard != nil {
+		defer self.Keyboard.closeFeedback()
+	}
+	for {
+		event, err := readEvent(fd)
+		if err != nil {
+			return
+		}
+		switch {
+		case event.Type == uinputEvent:
+			// NOTE: A failed answer leaves the request to time out in the
+			// kernel, there is no one else to report it to.
+			answerForceFeedback(fd, event)
+		case self.Keyboard == nil:
+		case event.Type == ledEvent.UInt16():
+			self.Keyboard.setLED(EventCode(event.Code), event.Value != 0)
+		case event.Type == soundEvent.UInt16() && EventCode(event.Code) == SND_BELL:
+			self.Keyboard.setBell(event.Value != 0)
+		}
+	}
+}
+
+func (self *VirtualKeyboard) setLED(led EventCode, on bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	leds := self.leds
+	switch led {
+	case LED_CAPSL:
+		leds.CapsLock = on
+		self.toggleStatus[CapsLock] = on
+	case LED_NUML:
+		leds.NumLock = on
+		self.toggleStatus[NumLock] = on
+	case LED_SCROLLL:
+		leds.ScrollLock = on
+		self.toggleStatus[ScrollLock] = on
+	case LED_COMPOSE:
+		leds.Compose = on
+		self.toggleStatus[Compose] = on
+	case LED_KANA:
+		leds.Kana = on
+		self.toggleStatus[Kana] = on
+	}
+	if leds == self.leds {
+		return
+	}
+	self.leds = leds
+	for _, changes := range self.ledChanges {
+		notify(changes, leds)
+	}
+}
+
+func (self *VirtualKeyboard) setBell(on bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.bell == on {
+		return
+	}
+	self.bell = on
+	for _, changes := range self.bellChanges {
+		notify(changes, on)
+	}
+}
+
+func (self *VirtualKeyboard) closeFeedback() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.feedbackDone = true
+	for _, changes := range self.ledChanges {
+		close(changes)
+	}
+	for _, changes := range self.bellChanges {
+		close(changes)
+	}
+	self.ledChanges, self.bellChanges = nil, nil
+}
+
+// notify hands the newest value to a listener without blocking the read
+// loop; a listener that fell behind only misses the states in between.
+func notify[T any](changes chan T, value T) {
+	select {
+	case <-changes:
+	default:
+	}
+	changes <- value
+}
+
+func (self *Device) LEDs() LEDState {
+	if self.Keyboard == nil {
+		return LEDState{}
+	}
+	self.Keyboard.mutex.Lock()
+	defer self.Keyboard.mutex.Unlock()
+	return self.Keyboard.leds
+}
+
+func (self *Device) ToggleState(key ToggleKey) bool {
+	if self.Keyboard == nil {
+		return false
+	}
+	return self.Keyboard.ToggleStatus(key)
+}
+
+func (self *Device) BellRinging() bool {
+	if self.Keyboard == nil {
+		return false
+	}
+	self.Keyboard.mutex.Lock()
+	defer self.Keyboard.mutex.Unlock()
+	return self.Keyboard.bell
+}
+
+// LEDChanges returns a channel receiving the LED state each time it changes,
+// closed once the device is disconnected. Returns nil for devices that are
+// not keyboards.
+func (self *Device) LEDChanges() <-chan LEDState {
+	if self.Keyboard == nil {
+		return nil
+	}
+	changes := make(chan LEDState, 1)
+	self.Keyboard.mutex.Lock()
+	defer self.Keyboard.mutex.Unlock()
+	if self.Keyboard.feedbackDone {
+		close(changes)
+		return changes
+	}
+	self.Keyboard.ledChanges = append(self.Keyboard.ledChanges, changes)
+	return changes
+}
+
+// BellChanges returns a channel receiving the bell state each time the
+// consumer starts or stops ringing it, closed once the device is
+// disconnected. Returns nil for devices that are not keyboards.
+func (self *Device) BellChanges() <-chan bool {
+	if self.Keyboard == nil {
+		return nil
+	}
+	changes := make(chan bool, 1)
+	self.Keyboard.mutex.Lock()
+	defer self.Keyboard.mutex.Unlock()
+	if self.Keyboard.feedbackDone {
+		close(changes)
+		return changes
+	}
+	self.Keyboard.bellChanges = append(self.Keyboard.bellChanges, changes)
+	return changes
+}
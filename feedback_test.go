@@ -0,0 +1,20 @@
+package uinput
+
+import "testing"
+
+func TestToggleStatusFollowsLEDs(t *testing.T) {
+	keyboard := newVirtualKeyboard()
+	device := &Device{Type: Keyboard, Keyboard: keyboard}
+	keyboard.setLED(LED_CAPSL, true)
+	keyboard.setLED(LED_NUML, true)
+	keyboard.setLED(LED_NUML, false)
+	if !keyboard.ToggleStatus(CapsLock) || !device.ToggleState(CapsLock) {
+		t.Error("Caps Lock off after its LED turned on")
+	}
+	if keyboard.ToggleStatus(NumLock) || device.ToggleState(NumLock) {
+		t.Error("Num Lock on after its LED turned off")
+	}
+	if leds := device.LEDs(); leds != (LEDState{CapsLock: true}) {
+		t.Errorf("LEDs %+v, want only Caps Lock", leds)
+	}
+}
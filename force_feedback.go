@@ -0,0 +1,81 @@
+package uinput
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// ffPeriodic mirrors struct ff_periodic_effect from input.h, the largest
+// member of the ff_effect union, so ffEffect has the size of the kernel one.
+type ffPeriodic struct {
+	Waveform   uint16
+	Period     uint16
+	Magnitude  int16
+	Offset     int16
+	Phase      uint16
+	Envelope   [4]uint16
+	CustomLen  uint32
+	CustomData uintptr
+}
+
+// ffEffect mirrors struct ff_effect from input.h.
+type ffEffect struct {
+	Type      uint16
+	Id        int16
+	Direction uint16
+	Trigger   [2]uint16
+	Replay    [2]uint16
+	Periodic  ffPeriodic
+}
+
+// uinputFFUpload mirrors struct uinput_ff_upload from uinput.h.
+type uinputFFUpload struct {
+	RequestId uint32
+	Retval    int32
+	Effect    ffEffect
+	Old       ffEffect
+}
+
+// uinputFFErase mirrors struct uinput_ff_erase from uinput.h.
+type uinputFFErase struct {
+	RequestId uint32
+	Retval    int32
+	EffectId  uint32
+}
+
+// UI_BEGIN_FF_UPLOAD, UI_END_FF_UPLOAD, UI_BEGIN_FF_ERASE and UI_END_FF_ERASE
+// from uinput.h, whose request numbers depend on the size of the structures.
+var (
+	beginFFUploadRequest = ioc(iocRead|iocWrite, 'U', uintptr(UI_BEGIN_FF_UPLOAD.ID()), unsafe.Sizeof(uinputFFUpload{}))
+	endFFUploadRequest   = ioc(iocWrite, 'U', uintptr(UI_END_FF_UPLOAD.ID()), unsafe.Sizeof(uinputFFUpload{}))
+	beginFFEraseRequest  = ioc(iocRead|iocWrite, 'U', uintptr(UI_BEGIN_FF_ERASE.ID()), unsafe.Sizeof(uinputFFErase{}))
+	endFFEraseRequest    = ioc(iocWrite, 'U', uintptr(UI_END_FF_ERASE.ID()), unsafe.Sizeof(uinputFFErase{}))
+)
+
+// answerForceFeedback accepts an effect upload or erase requested through an
+// EV_UINPUT event, which the kernel otherwise waits on until it times out.
+// Effects are accepted but never played.
+func answerForceFeedback(fd *os.File, event InputEvent) error {
+	switch event.Code {
+	case uinputForceFeedbackUpload:
+		upload := uinputFFUpload{RequestId: uint32(event.Value)}
+		if err := ioctl(fd, beginFFUploadRequest, uintptr(unsafe.Pointer(&upload))); err != nil {
+			return fmt.Errorf("[error] failed to begin force feedback upload: %v", err)
+		}
+		upload.Retval = 0
+		if err := ioctl(fd, endFFUploadRequest, uintptr(unsafe.Pointer(&upload))); err != nil {
+			return fmt.Errorf("[error] failed to end force feedback upload: %v", err)
+		}
+	case uinputForceFeedbackErase:
+		erase := uinputFFErase{RequestId: uint32(event.Value)}
+		if err := ioctl(fd, beginFFEraseRequest, uintptr(unsafe.Pointer(&erase))); err != nil {
+			return fmt.Errorf("[error] failed to begin force feedback erase: %v", err)
+		}
+		erase.Retval = 0
+		if err := ioctl(fd, endFFEraseRequest, uintptr(unsafe.Pointer(&erase))); err != nil {
+			return fmt.Errorf("[error] failed to end force feedback erase: %v", err)
+		}
+	}
+	return nil
+}
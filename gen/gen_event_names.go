@@ -0,0 +1,79 @@
+//go:build ignore
+
+// gen_event_names generates event_names.go, listing the symbolic name of
+// every event code declared in event_codes.go together with its event type.
+//
+//	go run gen/gen_event_names.go -codes event_codes.go -output event_names.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+)
+
+// prefixTypes maps constant name prefixes to the event type of their codes.
+var prefixTypes = []struct{ prefix, eventType string }{
+	{"KEY_", "EV_KEY"},
+	{"BTN_", "EV_KEY"},
+	{"REL_", "EV_REL"},
+	{"ABS_", "EV_ABS"},
+	{"MSC_", "EV_MSC"},
+	{"SW_", "EV_SW"},
+	{"LED_", "EV_LED"},
+	{"SND_", "EV_SND"},
+	{"REP_", "EV_REP"},
+}
+
+func main() {
+	// NOTE: Flags rather than positional arguments, go run would otherwise
+	// compile the .go files passed to the generator.
+	codes := flag.String("codes", "event_codes.go", "file declaring the EventCode constants")
+	output := flag.String("output", "event_names.go", "generated file")
+	flag.Parse()
+	file, err := parser.ParseFile(token.NewFileSet(), *codes, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gen/gen_event_names.go from event_codes.go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package uinput\n\n")
+	fmt.Fprintf(&out, "// eventCodeNames lists every named event code. When several names share a\n")
+	fmt.Fprintf(&out, "// code the last one listed is canonical, the earlier ones mark the start of\n")
+	fmt.Fprintf(&out, "// a range, e.g. BTN_MOUSE and BTN_LEFT.\n")
+	fmt.Fprintf(&out, "var eventCodeNames = []eventCodeName{\n")
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 {
+			return true
+		}
+		if ident, ok := spec.Type.(*ast.Ident); !ok || ident.Name != "EventCode" {
+			return true
+		}
+		name := spec.Names[0].Name
+		for _, prefixType := range prefixTypes {
+			if strings.HasPrefix(name, prefixType.prefix) {
+				fmt.Fprintf(&out, "\t{%v, %v, %q},\n", prefixType.eventType, name, name)
+				break
+			}
+		}
+		return true
+	})
+	fmt.Fprintf(&out, "}\n")
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
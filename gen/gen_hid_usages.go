@@ -0,0 +1,112 @@
+//go:build ignore
+
+// gen_hid_usages generates hid_usages.go, the table mapping key codes to the
+// HID keyboard page usage a physical keyboard reports in MSC_SCAN. The table
+// is inverted from hid_keyboard[] in the kernel's drivers/hid/hid-input.c so
+// the scancodes match what hid-input decodes.
+//
+//	go run gen/gen_hid_usages.go <hid-input.c> <event_codes.go> <output.go>
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Usages from 0xe8 upwards are Linux specific remappings rather than usages
+// defined by the HID usage tables, so no physical keyboard reports them.
+const lastStandardUsage = 0xe7
+
+var tableRegexp = regexp.MustCompile(`(?s)hid_keyboard\[256\]\s*=\s*\{(.*?)\};`)
+
+func main() {
+	if len(os.Args) != 4 {
+		log.Fatalf("usage: %v <hid-input.c> <event_codes.go> <output.go>", os.Args[0])
+	}
+	source, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	ms := tableRegexp.FindSubmatch(source)
+	if ms == nil {
+		log.Fatalf("hid_keyboard table not found in %v", os.Args[1])
+	}
+	names, err := keyNames(os.Args[2])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gen/gen_hid_usages.go from hid-input.c. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package uinput\n\n")
+	fmt.Fprintf(&out, "// hidKeyboardUsages maps key codes to their usage on the HID keyboard page.\n")
+	fmt.Fprintf(&out, "var hidKeyboardUsages = map[EventCode]uint16{\n")
+	seen := make(map[int]bool)
+	for usage, field := range strings.Split(string(ms[1]), ",") {
+		field = strings.TrimSpace(field)
+		if usage > lastStandardUsage || field == "" || field == "unk" {
+			continue
+		}
+		code, err := strconv.Atoi(field)
+		if err != nil {
+			log.Fatalf("invalid key code %q at usage 0x%02x", field, usage)
+		}
+		// Several usages map onto the same key; the first is the canonical one.
+		if code == 0 || seen[code] {
+			continue
+		}
+		seen[code] = true
+		name, ok := names[code]
+		if !ok {
+			name = fmt.Sprintf("EventCode(0x%x)", code)
+		}
+		fmt.Fprintf(&out, "\t%v: 0x%02x,\n", name, usage)
+	}
+	fmt.Fprintf(&out, "}\n")
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(os.Args[3], formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// keyNames returns the first KEY_ constant declared for each key code.
+func keyNames(path string) (map[int]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string)
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || len(spec.Values) != 1 {
+			return true
+		}
+		name := spec.Names[0].Name
+		literal, ok := spec.Values[0].(*ast.BasicLit)
+		if !ok || !strings.HasPrefix(name, "KEY_") {
+			return true
+		}
+		value, err := strconv.ParseInt(literal.Value, 0, 32)
+		if err != nil {
+			return true
+		}
+		if _, exists := names[int(value)]; !exists {
+			names[int(value)] = name
+		}
+		return true
+	})
+	return names, nil
+}
@@ -2,4 +2,7 @@ module github.com/multiverse-os/uinput
 
 go 1.19
 
-require github.com/google/gousb v1.1.2
+require (
+	github.com/BurntSushi/toml v1.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
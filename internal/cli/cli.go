@@ -0,0 +1,624 @@
+// Package cli is the command line of the uinput and uinput-cli commands, which
+// drive virtual input devices and inspect real ones in place of xdotool,
+// ydotool, evtest and evemu:
+//
+//	uinput list [--json]
+//	uinput type [--dry-run] "text"
+//	uinput key [--dry-run] ctrl+s [keys...]
+//	uinput click [--dry-run] [--button left] [--count 1]
+//	uinput move [--dry-run] [--absolute --screen 1920x1080] [--over 300ms] x y
+//	uinput scroll [--dry-run] [--horizontal] clicks
+//	uinput create --config device.yaml
+//	uinput monitor [--json] /dev/input/event3
+//	uinput record [--format evemu] /dev/input/event3 session.evemu
+//	uinput replay [--format evemu] [--speed 1] session.evemu
+//	uinput ids lookup [--json] vendor [product]
+//	uinput ids search [--json] words...
+//	uinput daemon [--socket path] [--screen 1920x1080]
+//	uinput send [--socket path] [--touch] "macro"
+//	uinput doctor [--json]
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/multiverse-os/uinput"
+	usb "github.com/multiverse-os/uinput/usb-id"
+)
+
+// settleTime gives userspace time to pick up a new virtual device before it
+// is used, otherwise its first events are lost.
+const settleTime = 200 * time.Millisecond
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands map[string]command
+
+// program is the name the usage is printed with.
+var program string
+
+func init() {
+	commands = map[string]command{
+		"list":    {"list [--json]", list},
+		"type":    {`type [--dry-run] "text"`, typeText},
+		"key":     {"key [--dry-run] ctrl+s [keys...]", key},
+		"click":   {"click [--dry-run] [--button left] [--count 1]", click},
+		"move":    {"move [--dry-run] [--absolute --screen 1920x1080] [--over 300ms] x y", move},
+		"scroll":  {"scroll [--dry-run] [--horizontal] clicks", scroll},
+		"create":  {"create --config device.{yaml,json,toml}", create},
+		"monitor": {"monitor [--json] /dev/input/eventN", monitor},
+		"record":  {"record [--format evemu|jsonl|binary] /dev/input/eventN file", record},
+		"replay":  {"replay [--format evemu|jsonl|binary] [--speed 1] file", replay},
+		"ids":     {"ids lookup|search [--json] vendor [product] | words...", ids},
+		"daemon":  {"daemon [--socket path] [--screen 1920x1080]", daemon},
+		"send":    {`send [--socket path] [--touch] "macro"`, send},
+		"doctor":  {"doctor [--json]", doctor},
+	}
+}
+
+// Main runs the command named by the arguments, exiting with its status. name
+// is the program name printed in the usage.
+func Main(name string) {
+	program = name
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, name := range []string{"list", "type", "key", "click", "move", "scroll", "create", "monitor", "record", "replay", "ids", "daemon", "send", "doctor"} {
+		fmt.Fprintf(os.Stderr, "  %v %v\n", program, commands[name].usage)
+	}
+}
+
+// flags parses the flags of a command, requiring between min and max
+// positional arguments; max is ignored when negative.
+func flags(name string, set *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	set.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %v %v\n", program, commands[name].usage)
+		set.PrintDefaults()
+	}
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+	if set.NArg() < min || (max >= 0 && set.NArg() > max) {
+		set.Usage()
+		return nil, fmt.Errorf("[error] %v: wrong number of arguments", name)
+	}
+	return set.Args(), nil
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// interruptible returns a context done on SIGINT or SIGTERM.
+func interruptible() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// connect creates a virtual device and waits for userspace to pick it up.
+func connect(device uinput.VirtualDevice, err error) (*uinput.Device, error) {
+	if err != nil {
+		return nil, err
+	}
+	connected, err := device.Connect()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(settleTime)
+	return connected.(*uinput.Device), nil
+}
+
+func list(args []string) error {
+	set := flag.NewFlagSet("list", flag.ExitOnError)
+	asJSON := set.Bool("json", false, "print JSON")
+	if _, err := flags("list", set, args, 0, 0); err != nil {
+		return err
+	}
+	devices, err := uinput.ListDevices()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(devices)
+	}
+	for _, device := range devices {
+		path := device.Path
+		if path == "" {
+			path = "-"
+		}
+		fmt.Printf("%-20v %v  %v\n", path, device.Description.Id, device.Description.Name)
+	}
+	return nil
+}
+
+// open creates a virtual device, or with dryRun one printing the frames it
+// would inject instead.
+func open(deviceType uinput.DeviceType, name string, dryRun bool) (*uinput.Device, error) {
+	if dryRun {
+		return deviceType.DryRun(name, uinput.Trace{Writer: os.Stdout})
+	}
+	return connect(deviceType.Create(name))
+}
+
+func dryRunFlag(set *flag.FlagSet) *bool {
+	return set.Bool("dry-run", false, "print the events instead of injecting them")
+}
+
+func typeText(args []string) error {
+	set := flag.NewFlagSet("type", flag.ExitOnError)
+	dryRun := dryRunFlag(set)
+	args, err := flags("type", set, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	device, err := open(uinput.Keyboard, "uinput-cli keyboard", *dryRun)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+	return device.TypeText(strings.Join(args, " "))
+}
+
+func key(args []string) error {
+	set := flag.NewFlagSet("key", flag.ExitOnError)
+	dryRun := dryRunFlag(set)
+	args, err := flags("key", set, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	device, err := open(uinput.Keyboard, "uinput-cli keyboard", *dryRun)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+	for _, accel := range args {
+		if err := device.Accel(accel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var buttons = map[string]uinput.ButtonType{
+	"left":   uinput.LeftButton,
+	"right":  uinput.RightButton,
+	"middle": uinput.MiddleButton,
+}
+
+func click(args []string) error {
+	set := flag.NewFlagSet("click", flag.ExitOnError)
+	buttonName := set.String("button", "left", "left, right or middle")
+	count := set.Int("count", 1, "number of clicks")
+	dryRun := dryRunFlag(set)
+	if _, err := flags("click", set, args, 0, 0); err != nil {
+		return err
+	}
+	button, ok := buttons[*buttonName]
+	if !ok {
+		return fmt.Errorf("[error] unknown button %q", *buttonName)
+	}
+	device, err := open(uinput.Mouse, "uinput-cli mouse", *dryRun)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+	return device.MultiClick(button, *count)
+}
+
+func move(args []string) error {
+	set := flag.NewFlagSet("move", flag.ExitOnError)
+	absolute := set.Bool("absolute", false, "move to x,y on the screen rather than by x,y")
+	screen := set.String("screen", "1920x1080", "screen size of absolute moves")
+	over := set.Duration("over", 0, "spread the move over a duration")
+	dryRun := dryRunFlag(set)
+	args, err := flags("move", set, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	x, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("[error] invalid x %q", args[0])
+	}
+	y, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("[error] invalid y %q", args[1])
+	}
+	var device *uinput.Device
+	if *absolute && *dryRun {
+		device, err = open(uinput.Touchpad, "uinput-cli touchpad", true)
+		if err != nil {
+			return err
+		}
+	} else if *absolute {
+		var width, height int32
+		if _, err := fmt.Sscanf(*screen, "%dx%d", &width, &height); err != nil {
+			return fmt.Errorf("[error] invalid screen size %q", *screen)
+		}
+		created, err := uinput.Touchpad.Create("uinput-cli touchpad")
+		if err != nil {
+			return err
+		}
+		device, err = connect(created.(*uinput.Device).ScreenSize(width, height), nil)
+		if err != nil {
+			return err
+		}
+	} else if device, err = open(uinput.Mouse, "uinput-cli mouse", *dryRun); err != nil {
+		return err
+	}
+	defer device.Disconnect()
+	macro, err := uinput.ParseMacro(fmt.Sprintf("move %d,%d over %v", x, y, *over))
+	if err != nil {
+		return err
+	}
+	return macro.Run(context.Background(), nil, device)
+}
+
+func scroll(args []string) error {
+	set := flag.NewFlagSet("scroll", flag.ExitOnError)
+	horizontal := set.Bool("horizontal", false, "scroll horizontally, right for positive clicks")
+	dryRun := dryRunFlag(set)
+	args, err := flags("scroll", set, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	clicks, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("[error] invalid clicks %q", args[0])
+	}
+	device, err := open(uinput.Mouse, "uinput-cli mouse", *dryRun)
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+	if *horizontal {
+		return device.HorizontalScroll(int32(clicks))
+	}
+	return device.Scroll(int32(clicks))
+}
+
+func create(args []string) error {
+	set := flag.NewFlagSet("create", flag.ExitOnError)
+	config := set.String("config", "", "device config in YAML, JSON or TOML, or a description written by list --json")
+	if _, err := flags("create", set, args, 0, 0); err != nil {
+		return err
+	}
+	if *config == "" {
+		set.Usage()
+		return fmt.Errorf("[error] create: --config is required")
+	}
+	description, err := loadDescription(*config)
+	if err != nil {
+		return err
+	}
+	device, err := description.Connect()
+	if err != nil {
+		return err
+	}
+	defer device.Disconnect()
+	fmt.Fprintf(os.Stderr, "created %q, interrupt to remove it\n", description.Name)
+	ctx, cancel := interruptible()
+	defer cancel()
+	<-ctx.Done()
+	return nil
+}
+
+// loadDescription reads a device config, or a description as list --json
+// writes them, told apart by its "capabilities".
+func loadDescription(path string) (uinput.DeviceDescription, error) {
+	var description uinput.DeviceDescription
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return description, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err == nil && fields["capabilities"] != nil {
+			if err := json.Unmarshal(data, &description); err != nil {
+				return description, fmt.Errorf("[error] invalid device description %v: %v", path, err)
+			}
+			return description, nil
+		}
+	}
+	config, err := uinput.LoadDeviceConfig(path)
+	if err != nil {
+		return description, err
+	}
+	return config.Description()
+}
+
+func monitor(args []string) error {
+	set := flag.NewFlagSet("monitor", flag.ExitOnError)
+	asJSON := set.Bool("json", false, "print JSON lines")
+	args, err := flags("monitor", set, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	reader, err := uinput.OpenEventReader(args[0])
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	description, err := reader.Description()
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	encoder := json.NewEncoder(out)
+	if *asJSON {
+		if err := encoder.Encode(description); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(out, "Input device name: %q\nInput device ID: %v\n", description.Name, description.Id)
+	}
+	out.Flush()
+	ctx, cancel := interruptible()
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		reader.Close()
+	}()
+	for {
+		event, err := reader.ReadEvent()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if *asJSON {
+			err = encoder.Encode(event)
+		} else {
+			eventType := uinput.MarshalEventType(int(event.Type))
+			_, err = fmt.Fprintf(out, "Event: time %d.%06d, type %d (%v), code %d (%v), value %d\n",
+				event.Time.Sec, event.Time.Usec, event.Type, eventType, event.Code,
+				uinput.CodeName(eventType, uinput.EventCode(event.Code)), event.Value)
+		}
+		if err != nil {
+			return err
+		}
+		if event.Type == uinput.EV_SYN.Code() {
+			out.Flush()
+		}
+	}
+}
+
+// sessionFormat picks the session format from the flag, or from the file
+// extension when the flag is empty: .jsonl, .bin, evemu otherwise.
+func sessionFormat(format, path string) (string, error) {
+	if format == "" {
+		switch filepath.Ext(path) {
+		case ".jsonl", ".json":
+			return "jsonl", nil
+		case ".bin", ".uievlog":
+			return "binary", nil
+		default:
+			return "evemu", nil
+		}
+	}
+	switch format {
+	case "evemu", "jsonl", "binary":
+		return format, nil
+	}
+	return "", fmt.Errorf("[error] unknown session format %q", format)
+}
+
+func record(args []string) error {
+	set := flag.NewFlagSet("record", flag.ExitOnError)
+	formatName := set.String("format", "", "evemu, jsonl or binary, from the file extension by default")
+	args, err := flags("record", set, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	format, err := sessionFormat(*formatName, args[1])
+	if err != nil {
+		return err
+	}
+	var file io.WriteCloser = os.Stdout
+	if args[1] != "-" {
+		if file, err = os.Create(args[1]); err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+	out := bufio.NewWriter(file)
+	var session uinput.SessionWriter
+	switch format {
+	case "jsonl":
+		session = uinput.NewJSONSessionWriter(out)
+	case "binary":
+		session = uinput.NewBinarySessionWriter(out)
+	default:
+		session = uinput.NewEvemuWriter(out)
+	}
+	ctx, cancel := interruptible()
+	defer cancel()
+	if err := uinput.Record(ctx, args[0], session); err != nil {
+		return err
+	}
+	if binary, ok := session.(*uinput.BinarySessionWriter); ok {
+		if err := binary.Flush(); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+func replay(args []string) error {
+	set := flag.NewFlagSet("replay", flag.ExitOnError)
+	formatName := set.String("format", "", "evemu, jsonl or binary, from the file extension by default")
+	speed := set.Float64("speed", 1, "replay speed, 0 replays without delays")
+	args, err := flags("replay", set, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	format, err := sessionFormat(*formatName, args[0])
+	if err != nil {
+		return err
+	}
+	var file io.ReadCloser = os.Stdin
+	if args[0] != "-" {
+		if file, err = os.Open(args[0]); err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+	in := bufio.NewReader(file)
+	var session uinput.SessionReader
+	switch format {
+	case "jsonl":
+		session = uinput.NewJSONSessionReader(in)
+	case "binary":
+		session = uinput.NewBinarySessionReader(in)
+	default:
+		session = uinput.NewEvemuReader(in)
+	}
+	ctx, cancel := interruptible()
+	defer cancel()
+	return uinput.Replay(ctx, session, *speed)
+}
+
+func ids(args []string) error {
+	if len(args) == 0 || (args[0] != "lookup" && args[0] != "search") {
+		fmt.Fprintf(os.Stderr, "usage: %v %v\n", program, commands["ids"].usage)
+		return fmt.Errorf("[error] ids: unknown subcommand")
+	}
+	set := flag.NewFlagSet("ids", flag.ExitOnError)
+	asJSON := set.Bool("json", false, "print JSON")
+	var products []usb.Product
+	if args[0] == "search" {
+		query, err := flags("ids", set, args[1:], 1, -1)
+		if err != nil {
+			return err
+		}
+		products = usb.Search(strings.Join(query, " "))
+	} else {
+		ids, err := flags("ids", set, args[1:], 1, 2)
+		if err != nil {
+			return err
+		}
+		values := make([]uint16, len(ids))
+		for n, id := range ids {
+			value, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(id), "0x"), 16, 16)
+			if err != nil {
+				return fmt.Errorf("[error] invalid usb id %q", id)
+			}
+			values[n] = uint16(value)
+		}
+		if len(values) == 2 {
+			if product, ok := usb.LookupProduct(values[0], values[1]); ok {
+				products = append(products, product)
+			}
+		} else if vendor, ok := usb.LookupVendor(values[0]); ok {
+			products = vendor.Products
+		}
+	}
+	if *asJSON {
+		if products == nil {
+			products = []usb.Product{}
+		}
+		return printJSON(products)
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("[error] no usb ids match %v", strings.Join(args[1:], " "))
+	}
+	for _, product := range products {
+		fmt.Printf("%04x:%04x  %v  %v\n", product.VendorID, product.ID, product.VendorName, product.Name)
+	}
+	return nil
+}
+
+func daemon(args []string) error {
+	set := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socket := set.String("socket", uinput.DefaultSocketPath(), "socket to listen on")
+	screen := set.String("screen", "", "screen size, creating a touchpad for absolute moves")
+	if _, err := flags("daemon", set, args, 0, 0); err != nil {
+		return err
+	}
+	daemon := &uinput.Daemon{Name: "uinput-cli"}
+	if *screen != "" {
+		if _, err := fmt.Sscanf(*screen, "%dx%d", &daemon.Screen.Width, &daemon.Screen.Height); err != nil {
+			return fmt.Errorf("[error] invalid screen size %q", *screen)
+		}
+	}
+	ctx, cancel := interruptible()
+	defer cancel()
+	return daemon.ListenAndServe(ctx, *socket)
+}
+
+func send(args []string) error {
+	set := flag.NewFlagSet("send", flag.ExitOnError)
+	socket := set.String("socket", uinput.DefaultSocketPath(), "socket of the daemon")
+	touch := set.Bool("touch", false, "run pointer commands on the touchpad")
+	args, err := flags("send", set, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	client, err := uinput.DialDaemon(*socket)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	for _, macro := range args {
+		run := client.Run
+		if *touch {
+			run = client.RunTouch
+		}
+		if err := run(macro); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func doctor(args []string) error {
+	set := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := set.Bool("json", false, "print JSON")
+	if _, err := flags("doctor", set, args, 0, 0); err != nil {
+		return err
+	}
+	diagnosis := uinput.Diagnose()
+	if *asJSON {
+		if err := printJSON(diagnosis); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(diagnosis)
+	}
+	if !diagnosis.OK() {
+		return fmt.Errorf("[error] virtual devices cannot be created")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package uinput
 import (
 	"os"
 	"syscall"
+	"unsafe"
 )
 
 // Original function taken from: https://github.com/tianon/debian-golang-pty/blob/master/ioctl.go
@@ -103,6 +104,14 @@ const (
 	RelativeBit      = UI_SET_RELBIT
 	AbsoluteMovement = UI_SET_ABSBIT
 	AbsoluteBit      = UI_SET_ABSBIT
+	SwitchBit        = UI_SET_SWBIT
+	LEDBit           = UI_SET_LEDBIT
+	SoundBit         = UI_SET_SNDBIT
+	MiscBit          = UI_SET_MSCBIT
+	ForceFeedbackBit = UI_SET_FFBIT
+	PropertyBit      = UI_SET_PROPBIT
+	AbsoluteSetup    = UI_ABS_SETUP
+	PhysicalPath     = UI_SET_PHYS
 )
 
 func (self ioctlType) ID() int {
@@ -177,7 +186,9 @@ func (self ioctlType) UIntPointer() uintptr {
 	case UI_SET_FFBIT:
 		return 0x4004556b
 	case UI_SET_PHYS:
-		return 0x4004556c
+		// NOTE: Declared as _IOW(UINPUT_IOCTL_BASE, 108, char*), so the
+		// request number depends on the pointer size.
+		return ioc(iocWrite, 'U', 108, unsafe.Sizeof(uintptr(0)))
 	case UI_SET_SWBIT:
 		return 0x4004556d
 	case UI_SET_PROPBIT:
@@ -190,3 +201,9 @@ func (self ioctlType) UIntPointer() uintptr {
 func (self ioctlType) Code() uintptr {
 	return self.UIntPointer()
 }
+
+// ioc encodes an ioctl request number the same way as the _IOC macro in
+// asm-generic/ioctl.h.
+func ioc(dir iocDir, typ, nr, size uintptr) uintptr {
+	return uintptr(dir)<<iocDirShift | typ<<iocTypeShift | nr<<iocNrShift | size<<iocSizeShift
+}
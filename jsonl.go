@@ -0,0 +1,76 @@
+package uinput
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The JSON lines session format holds the device description on its first
+// line, then one event per line timed in seconds from the first event:
+//
+//	{"device":{"name":"Logitech USB Receiver","id":{...},"capabilities":{...}}}
+//	{"t":0,"type":"EV_REL","code":"REL_X","value":-1}
+//	{"t":0,"type":"EV_SYN","code":"SYN_REPORT","value":0}
+type jsonHeader struct {
+	Device DeviceDescription `json:"device"`
+}
+
+type JSONSessionWriter struct {
+	encoder *json.Encoder
+	start   sessionStart
+}
+
+func NewJSONSessionWriter(w io.Writer) *JSONSessionWriter {
+	return &JSONSessionWriter{encoder: json.NewEncoder(w)}
+}
+
+func (self *JSONSessionWriter) WriteDescription(description DeviceDescription) error {
+	return self.encoder.Encode(jsonHeader{Device: description})
+}
+
+func (self *JSONSessionWriter) WriteEvent(event InputEvent) error {
+	event.Time = self.start.offset(event)
+	return self.encoder.Encode(event)
+}
+
+type JSONSessionReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewJSONSessionReader(r io.Reader) *JSONSessionReader {
+	scanner := bufio.NewScanner(r)
+	// NOTE: Descriptions of full keyboards are a few kilobytes on one line.
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &JSONSessionReader{scanner: scanner}
+}
+
+func (self *JSONSessionReader) next(value interface{}) error {
+	for self.scanner.Scan() {
+		self.line++
+		if len(self.scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(self.scanner.Bytes(), value); err != nil {
+			return fmt.Errorf("[error] json session line %d: %v", self.line, err)
+		}
+		return nil
+	}
+	if err := self.scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func (self *JSONSessionReader) ReadDescription() (DeviceDescription, error) {
+	var header jsonHeader
+	err := self.next(&header)
+	return header.Device, err
+}
+
+func (self *JSONSessionReader) ReadEvent() (event InputEvent, err error) {
+	err = self.next(&event)
+	return event, err
+}
@@ -184,3 +184,17 @@ func parseAccel(accel string) ([]EventCode, error) {
 	}
 	return keys, nil
 }
+
+// runeKey returns the key typing r and whether Shift must be held for it.
+func runeKey(r rune) (code EventCode, shift bool, err error) {
+	if r == '\n' {
+		return KEY_ENTER, false, nil
+	}
+	if code, ok := runeKeyCodes[r]; ok {
+		return code, false, nil
+	}
+	if code, ok := shiftedRuneKeyCodes[r]; ok {
+		return code, true, nil
+	}
+	return 0, false, fmt.Errorf("[error] no key types %q", r)
+}
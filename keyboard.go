@@ -2,14 +2,39 @@ package uinput
 
 import (
 	"fmt"
+	"sync"
 )
 
+// VirtualKeyboard holds the state a keyboard learns from the consumers of its
+// events. The toggle state is updated from the LED state the kernel writes
+// back to the device and read through ToggleStatus. The keys held are part
+// of the device state, read through Device.IsPressed and Device.Snapshot.
 type VirtualKeyboard struct {
-	ToggleStatus map[ToggleKey]bool
-	KeyMap       map[EventCode]bool
+	mutex        sync.Mutex
+	toggleStatus map[ToggleKey]bool
+	leds         LEDState
+	bell         bool
+	ledChanges   []chan LEDState
+	bellChanges  []chan bool
+	// feedbackDone is set once the feedback reader stopped and closed the
+	// change channels.
+	feedbackDone bool
 }
 
-func NewKeyboard(name string) VirtualDevice {
+func newVirtualKeyboard() *VirtualKeyboard {
+	return &VirtualKeyboard{
+		toggleStatus: make(map[ToggleKey]bool),
+	}
+}
+
+// ToggleStatus tells whether the toggle key is on, as the LEDs last reported.
+func (self *VirtualKeyboard) ToggleStatus(key ToggleKey) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.toggleStatus[key]
+}
+
+func NewKeyboard(name string) (VirtualDevice, error) {
 	return Keyboard.New(name)
 }
 
@@ -20,9 +45,11 @@ const (
 	NumLock
 	ScrollLock
 	Insert
+	Compose
+	Kana
 )
 
-func (self Device) Tap(key EventCode) error {
+func (self *Device) Tap(key EventCode) error {
 	if err := self.PressKey(key); err != nil {
 		return err
 	}
@@ -32,16 +59,63 @@ func (self Device) Tap(key EventCode) error {
 	return nil
 }
 
-func (self Device) PressKey(key EventCode) error {
-	if err := sendButtonEvent(self.FD, int(key), KeyPressed.Code()); err != nil {
-		return fmt.Errorf("[error] failed to issue the KeyDown event: %v", err)
+func (self *Device) PressKey(key EventCode) error {
+	events := append(self.scanCodeEvents(key), buttonInputEvent(key, KeyPressed.Code()))
+	if err := self.writeFrame(events...); err != nil {
+		return fmt.Errorf("[error] failed to issue the KeyDown event: %w", err)
+	}
+	return nil
+}
+
+func (self *Device) ReleaseKey(key EventCode) error {
+	events := append(self.scanCodeEvents(key), buttonInputEvent(key, KeyReleased.Code()))
+	if err := self.writeFrame(events...); err != nil {
+		return fmt.Errorf("[error] failed to issue the KeyUp event: %w", err)
+	}
+	return nil
+}
+
+// Accel presses the keys of an accelerator such as "ctrl+shift+t" in order,
+// then releases them in reverse order.
+func (self *Device) Accel(accel string) error {
+	keys, err := parseAccel(accel)
+	if err != nil {
+		return err
+	}
+	return self.accel(keys)
+}
+
+func (self *Device) accel(keys []EventCode) error {
+	for n, key := range keys {
+		if err := self.PressKey(key); err != nil {
+			for n--; n >= 0; n-- {
+				self.ReleaseKey(keys[n])
+			}
+			return err
+		}
+	}
+	for n := len(keys) - 1; n >= 0; n-- {
+		if err := self.ReleaseKey(keys[n]); err != nil {
+			return err
+		}
 	}
-	return self.SyncEvents()
+	return nil
 }
 
-func (self Device) ReleaseKey(key EventCode) error {
-	if err := sendButtonEvent(self.FD, int(key), KeyReleased.Code()); err != nil {
-		return fmt.Errorf("[error] failed to issue the KeyUp event: %v", err)
+// TypeText types text on the default QWERTY layout, holding Shift where needed.
+func (self *Device) TypeText(text string) error {
+	for _, r := range text {
+		key, shift, err := runeKey(r)
+		if err != nil {
+			return err
+		}
+		keys := []EventCode{key}
+		if shift {
+			keys = []EventCode{KEY_LEFTSHIFT, key}
+		}
+		if err := self.accel(keys); err != nil {
+			return err
+		}
 	}
-	return self.SyncEvents()
+	return nil
 }
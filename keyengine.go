@@ -0,0 +1,555 @@
+package uinput
+
+import (
+	"time"
+)
+
+// The key engine remaps keyboards the way QMK firmware does: keys acting as
+// one key when tapped and a modifier or layer when held, momentary and
+// toggled layers, combos of keys pressed together and one-shot modifiers.
+
+type actionKind uint8
+
+const (
+	transparentAction actionKind = iota
+	keyAction
+	noAction
+	modTapAction
+	layerTapAction
+	momentaryAction
+	toggleAction
+	oneShotAction
+)
+
+// KeyAction is what pressing a key does on a layer. The zero value is
+// Transparent.
+type KeyAction struct {
+	kind  actionKind
+	code  EventCode // key sent, or sent on tap
+	hold  EventCode // modifier held
+	layer int
+}
+
+// Transparent falls through to the next active layer below, down to the key
+// itself.
+var Transparent = KeyAction{}
+
+// NoKey disables the key.
+var NoKey = KeyAction{kind: noAction}
+
+func SendKey(code EventCode) KeyAction {
+	return KeyAction{kind: keyAction, code: code}
+}
+
+// ModTap sends tap when tapped and holds the modifier mod when held, e.g.
+// ModTap(KEY_LEFTCTRL, KEY_ESC).
+func ModTap(mod, tap EventCode) KeyAction {
+	return KeyAction{kind: modTapAction, code: tap, hold: mod}
+}
+
+// LayerTap sends tap when tapped and activates layer while held.
+func LayerTap(layer int, tap EventCode) KeyAction {
+	return KeyAction{kind: layerTapAction, code: tap, layer: layer}
+}
+
+// MomentaryLayer activates layer while held.
+func MomentaryLayer(layer int) KeyAction {
+	return KeyAction{kind: momentaryAction, layer: layer}
+}
+
+// ToggleLayer switches layer on or off on each press.
+func ToggleLayer(layer int) KeyAction {
+	return KeyAction{kind: toggleAction, layer: layer}
+}
+
+// OneShotMod applies the modifier mod to the next key pressed after it was
+// tapped, or acts as mod while held together with other keys.
+func OneShotMod(mod EventCode) KeyAction {
+	return KeyAction{kind: oneShotAction, hold: mod}
+}
+
+func (self KeyAction) tapHold() bool {
+	return self.kind == modTapAction || self.kind == layerTapAction
+}
+
+// Layer maps keys to their action while the layer is active. Keys missing from
+// a layer are Transparent.
+type Layer map[EventCode]KeyAction
+
+// Combo sends Output while all of Keys are held, when they are all pressed
+// within the combo term of each other.
+type Combo struct {
+	Keys   []EventCode
+	Output EventCode
+}
+
+const (
+	DefaultTappingTerm = 200 * time.Millisecond
+	DefaultComboTerm   = 50 * time.Millisecond
+)
+
+// queuedKey is a key event waiting on the engine to decide what it does.
+type queuedKey struct {
+	code  EventCode
+	value int32
+	time  time.Time
+	// comboChecked marks presses that turned out not to start a combo.
+	comboChecked bool
+}
+
+// heldKey is the action a key resolved to when pressed, undone when it is
+// released.
+type heldKey struct {
+	action KeyAction
+	tapped bool
+	// interrupted marks a one-shot modifier held while another key was
+	// pressed, which then acts as a plain modifier.
+	interrupted bool
+	// oneShots are the one-shot modifiers released along with the key.
+	oneShots []EventCode
+}
+
+// activeCombo is a combo whose keys are still held.
+type activeCombo struct {
+	combo    *Combo
+	released bool
+	held     map[EventCode]bool
+	oneShots []EventCode
+}
+
+// KeyEngine is a Transform applying layers, tap-hold keys, combos and one-shot
+// modifiers to the key events passing through it. Keys it has no action for
+// pass through unchanged, and so do events other than keys.
+type KeyEngine struct {
+	// Layers are stacked on top of the base layer 0, the highest active layer
+	// deciding the action of a key.
+	Layers []Layer
+	Combos []Combo
+	// TappingTerm is how long a tap-hold key can be held and still count as a
+	// tap, DefaultTappingTerm when zero.
+	TappingTerm time.Duration
+	// PermissiveHold decides a tap-hold key is held as soon as another key is
+	// tapped while it is down, rather than when the tapping term ends.
+	PermissiveHold bool
+	// ComboTerm is how close together the keys of a combo must be pressed,
+	// DefaultComboTerm when zero.
+	ComboTerm time.Duration
+	// OneShotTimeout is how long a tapped one-shot modifier waits for the
+	// next key before it is released; zero waits for as long as it takes.
+	OneShotTimeout time.Duration
+	// Clock times the terms and timeouts, SystemClock when nil
+	Clock Clock
+
+	queue     []queuedKey
+	held      map[EventCode]*heldKey
+	combos    map[EventCode]*activeCombo
+	momentary map[int]int
+	toggled   map[int]bool
+	oneShots  []EventCode
+	// oneShotTime is when the last one-shot modifier was tapped.
+	oneShotTime time.Time
+}
+
+// NewKeyEngine returns an engine with the default terms. A KeyEngine literal
+// works as well, the unset fields taking their defaults.
+func NewKeyEngine(layers ...Layer) *KeyEngine {
+	return &KeyEngine{
+		Layers:      layers,
+		TappingTerm: DefaultTappingTerm,
+		ComboTerm:   DefaultComboTerm,
+		Clock:       SystemClock,
+		held:        make(map[EventCode]*heldKey),
+		combos:      make(map[EventCode]*activeCombo),
+		momentary:   make(map[int]int),
+		toggled:     make(map[int]bool),
+	}
+}
+
+// Transform queues key events until what they do is decided, which for
+// tap-hold keys and combos may only be once later events arrive or once
+// Deadline passes.
+func (self *KeyEngine) Transform(event InputEvent, emit func(InputEvent)) {
+	switch {
+	case event.Type == EV_MSC.Code() && event.Code == uint16(MSC_SCAN):
+		// NOTE: Scan codes describe the physical key, which may no longer be
+		// the key reported.
+		return
+	case event.Type != EV_KEY.Code():
+		emit(event)
+		return
+	}
+	self.queue = append(self.queue, queuedKey{
+		code:  EventCode(event.Code),
+		value: event.Value,
+		time:  self.clock().Now(),
+	})
+	self.process(emit)
+}
+
+// Deadline is when the engine next needs Tick to be called, if it is waiting
+// on a tap-hold key, a combo or a one-shot modifier to time out.
+func (self *KeyEngine) Deadline() (deadline time.Time, ok bool) {
+	if len(self.queue) > 0 {
+		head := self.queue[0]
+		if head.value == 1 && !head.comboChecked && self.comboKey(head.code) {
+			deadline, ok = head.time.Add(self.comboTerm()), true
+		} else {
+			deadline, ok = head.time.Add(self.tappingTerm()), true
+		}
+	}
+	if expiry, expires := self.oneShotExpiry(); expires && (!ok || expiry.Before(deadline)) {
+		deadline, ok = expiry, true
+	}
+	return deadline, ok
+}
+
+// Now reads the engine Clock.
+func (self *KeyEngine) Now() time.Time {
+	return self.clock().Now()
+}
+
+// Tick decides the keys whose tapping or combo term has run out, and releases
+// one-shot modifiers past their timeout.
+func (self *KeyEngine) Tick(emit func(InputEvent)) {
+	self.process(emit)
+}
+
+// Layer reports whether layer is active.
+func (self *KeyEngine) Layer(layer int) bool {
+	return layer == 0 || self.momentary[layer] > 0 || self.toggled[layer]
+}
+
+// Capabilities lists the keys the engine may send, to be registered on the
+// device it writes to.
+func (self *KeyEngine) Capabilities() map[EventType][]EventCode {
+	codes := make(map[EventCode]bool)
+	for _, layer := range self.Layers {
+		for key, action := range layer {
+			codes[key] = true
+			for _, code := range []EventCode{action.code, action.hold} {
+				if code != 0 {
+					codes[code] = true
+				}
+			}
+		}
+	}
+	for _, combo := range self.Combos {
+		codes[combo.Output] = true
+	}
+	keys := make([]EventCode, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	return map[EventType][]EventCode{EV_KEY: keys}
+}
+
+func (self *KeyEngine) clock() Clock {
+	if self.Clock == nil {
+		return SystemClock
+	}
+	return self.Clock
+}
+
+func (self *KeyEngine) tappingTerm() time.Duration {
+	if self.TappingTerm == 0 {
+		return DefaultTappingTerm
+	}
+	return self.TappingTerm
+}
+
+func (self *KeyEngine) comboTerm() time.Duration {
+	if self.ComboTerm == 0 {
+		return DefaultComboTerm
+	}
+	return self.ComboTerm
+}
+
+func (self *KeyEngine) process(emit func(InputEvent)) {
+	if self.held == nil {
+		self.held = make(map[EventCode]*heldKey)
+		self.combos = make(map[EventCode]*activeCombo)
+		self.momentary = make(map[int]int)
+		self.toggled = make(map[int]bool)
+	}
+	now := self.clock().Now()
+	self.expireOneShots(now, emit)
+	for len(self.queue) > 0 {
+		head := self.queue[0]
+		if head.value != 1 {
+			self.queue = self.queue[1:]
+			self.release(head, emit)
+			continue
+		}
+		if !head.comboChecked && self.comboKey(head.code) {
+			if waiting := self.combo(now, emit); waiting {
+				return
+			}
+			continue
+		}
+		action := self.action(head.code)
+		if action.tapHold() {
+			tap, decided := self.decideTapHold(now)
+			if !decided {
+				return
+			}
+			self.queue = self.queue[1:]
+			self.interrupt()
+			key := &heldKey{action: action, tapped: tap}
+			self.held[head.code] = key
+			if tap {
+				self.pressKey(key, action.code, emit)
+			} else {
+				self.activate(action, emit)
+			}
+			continue
+		}
+		self.queue = self.queue[1:]
+		self.interrupt()
+		key := &heldKey{action: action}
+		self.held[head.code] = key
+		switch action.kind {
+		case keyAction:
+			self.pressKey(key, action.code, emit)
+		case momentaryAction:
+			self.momentary[action.layer]++
+		case toggleAction:
+			self.toggled[action.layer] = !self.toggled[action.layer]
+		case oneShotAction:
+			if !self.oneShotArmed(action.hold) {
+				emitKey(emit, action.hold, 1, now)
+			}
+		}
+	}
+}
+
+// decideTapHold decides whether the tap-hold key at the head of the queue is
+// tapped or held, once its release or the end of its tapping term is known.
+func (self *KeyEngine) decideTapHold(now time.Time) (tap, decided bool) {
+	head := self.queue[0]
+	deadline := head.time.Add(self.tappingTerm())
+	pressed := make(map[EventCode]bool)
+	for _, event := range self.queue[1:] {
+		if !event.time.Before(deadline) {
+			break
+		}
+		switch {
+		case event.code == head.code && event.value == 0:
+			return true, true
+		case event.value == 1:
+			pressed[event.code] = true
+		case event.value == 0 && pressed[event.code] && self.PermissiveHold:
+			return false, true
+		}
+	}
+	return false, !now.Before(deadline)
+}
+
+// combo handles the press at the head of the queue when it may start a combo,
+// reporting whether the engine must wait for more keys.
+func (self *KeyEngine) combo(now time.Time, emit func(InputEvent)) (waiting bool) {
+	head := self.queue[0]
+	deadline := head.time.Add(self.comboTerm())
+	pressed := map[EventCode]bool{}
+	interrupted := false
+	for _, event := range self.queue {
+		if event.value != 1 || !event.time.Before(deadline) || !self.comboKey(event.code) {
+			interrupted = true
+			break
+		}
+		pressed[event.code] = true
+	}
+	var matched *Combo
+	possible := false
+	for n := range self.Combos {
+		combo := &self.Combos[n]
+		if !containsKey(combo.Keys, head.code) {
+			continue
+		}
+		all, covered := true, 0
+		for _, key := range combo.Keys {
+			if pressed[key] {
+				covered++
+			} else {
+				all = false
+			}
+		}
+		if all && (matched == nil || len(combo.Keys) > len(matched.Keys)) {
+			matched = combo
+		} else if !all && covered == len(pressed) {
+			possible = true
+		}
+	}
+	if possible && !interrupted && now.Before(deadline) {
+		return true
+	}
+	if matched == nil {
+		self.queue[0].comboChecked = true
+		return false
+	}
+	active := &activeCombo{combo: matched, held: make(map[EventCode]bool)}
+	remaining := self.queue[:0]
+	for _, event := range self.queue {
+		if event.value == 1 && containsKey(matched.Keys, event.code) && !active.held[event.code] {
+			active.held[event.code] = true
+			self.combos[event.code] = active
+			continue
+		}
+		remaining = append(remaining, event)
+	}
+	self.queue = remaining
+	self.interrupt()
+	active.oneShots, self.oneShots = self.oneShots, nil
+	emitKey(emit, matched.Output, 1, now)
+	return false
+}
+
+func (self *KeyEngine) release(event queuedKey, emit func(InputEvent)) {
+	now := self.clock().Now()
+	if active, ok := self.combos[event.code]; ok {
+		// NOTE: The combo output is released with the first of its keys,
+		// releasing the others does nothing.
+		if event.value == 0 {
+			delete(self.combos, event.code)
+			if !active.released {
+				active.released = true
+				emitKey(emit, active.combo.Output, 0, now)
+				self.releaseOneShots(active.oneShots, emit)
+			}
+		}
+		return
+	}
+	key, ok := self.held[event.code]
+	if !ok {
+		// NOTE: Keys pressed before the engine started pass through.
+		emitKey(emit, event.code, event.value, now)
+		return
+	}
+	if event.value == 2 {
+		if key.action.kind == keyAction || key.tapped {
+			emitKey(emit, key.action.code, 2, now)
+		}
+		return
+	}
+	delete(self.held, event.code)
+	switch {
+	case key.action.kind == keyAction || key.tapped:
+		emitKey(emit, key.action.code, 0, now)
+		self.releaseOneShots(key.oneShots, emit)
+	case key.action.tapHold():
+		self.deactivate(key.action, emit)
+	case key.action.kind == momentaryAction:
+		self.momentary[key.action.layer]--
+	case key.action.kind == oneShotAction:
+		if key.interrupted {
+			emitKey(emit, key.action.hold, 0, now)
+		} else if !self.oneShotArmed(key.action.hold) {
+			self.oneShots = append(self.oneShots, key.action.hold)
+			self.oneShotTime = now
+		}
+	}
+}
+
+// action resolves what pressing code does on the active layers.
+func (self *KeyEngine) action(code EventCode) KeyAction {
+	for layer := len(self.Layers) - 1; layer >= 0; layer-- {
+		if !self.Layer(layer) {
+			continue
+		}
+		if action := self.Layers[layer][code]; action.kind != transparentAction {
+			return action
+		}
+	}
+	return SendKey(code)
+}
+
+func (self *KeyEngine) pressKey(key *heldKey, code EventCode, emit func(InputEvent)) {
+	key.oneShots, self.oneShots = self.oneShots, nil
+	emitKey(emit, code, 1, self.clock().Now())
+}
+
+func (self *KeyEngine) activate(action KeyAction, emit func(InputEvent)) {
+	if action.kind == modTapAction {
+		emitKey(emit, action.hold, 1, self.clock().Now())
+	} else {
+		self.momentary[action.layer]++
+	}
+}
+
+func (self *KeyEngine) deactivate(action KeyAction, emit func(InputEvent)) {
+	if action.kind == modTapAction {
+		emitKey(emit, action.hold, 0, self.clock().Now())
+	} else {
+		self.momentary[action.layer]--
+	}
+}
+
+// interrupt turns the one-shot modifiers being held into plain modifiers, as
+// another key is pressed.
+func (self *KeyEngine) interrupt() {
+	for _, key := range self.held {
+		if key.action.kind == oneShotAction {
+			key.interrupted = true
+		}
+	}
+}
+
+func (self *KeyEngine) oneShotArmed(mod EventCode) bool {
+	return containsKey(self.oneShots, mod)
+}
+
+func (self *KeyEngine) oneShotExpiry() (time.Time, bool) {
+	if len(self.oneShots) == 0 || self.OneShotTimeout <= 0 {
+		return time.Time{}, false
+	}
+	return self.oneShotTime.Add(self.OneShotTimeout), true
+}
+
+// expireOneShots releases the one-shot modifiers timed out before the next key
+// was pressed, judged by the time of the key at the head of the queue when
+// there is one.
+func (self *KeyEngine) expireOneShots(now time.Time, emit func(InputEvent)) {
+	expiry, expires := self.oneShotExpiry()
+	if !expires {
+		return
+	}
+	if len(self.queue) > 0 && self.queue[0].time.Before(now) {
+		now = self.queue[0].time
+	}
+	if now.Before(expiry) {
+		return
+	}
+	self.releaseOneShots(self.oneShots, emit)
+	self.oneShots = nil
+}
+
+func (self *KeyEngine) releaseOneShots(mods []EventCode, emit func(InputEvent)) {
+	for _, mod := range mods {
+		emitKey(emit, mod, 0, self.clock().Now())
+	}
+}
+
+func (self *KeyEngine) comboKey(code EventCode) bool {
+	for _, combo := range self.Combos {
+		if containsKey(combo.Keys, code) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKey(codes []EventCode, code EventCode) bool {
+	for _, key := range codes {
+		if key == code {
+			return true
+		}
+	}
+	return false
+}
+
+// emitKey emits a key event in a frame of its own, since the engine sends
+// keys long after the frame that caused them was synchronized.
+func emitKey(emit func(InputEvent), code EventCode, value int32, now time.Time) {
+	timestamp := NsecToTimeval(now.UnixNano())
+	emit(InputEvent{Time: timestamp, Type: EV_KEY.Code(), Code: uint16(code), Value: value})
+	emit(InputEvent{Time: timestamp, Type: EV_SYN.Code(), Code: uint16(ReportSync), Value: 0})
+}
@@ -0,0 +1,264 @@
+package uinput
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var engineStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// engineStep presses (1) or releases (0) key at an offset from the start, or
+// ticks the engine when key is zero.
+type engineStep struct {
+	at    time.Duration
+	key   EventCode
+	value int32
+}
+
+func tick(at time.Duration) engineStep {
+	return engineStep{at: at}
+}
+
+// runEngine feeds steps to engine on a FakeClock and returns the keys it sent,
+// as "KEY_A 1".
+func runEngine(t *testing.T, engine *KeyEngine, steps []engineStep) []string {
+	t.Helper()
+	clock := NewFakeClock(engineStart)
+	engine.Clock = clock
+	var sent []string
+	emit := func(event InputEvent) {
+		if event.Type == EV_KEY.Code() {
+			sent = append(sent, fmt.Sprintf("%v %d", CodeName(EV_KEY, EventCode(event.Code)), event.Value))
+		}
+	}
+	for _, step := range steps {
+		clock.Set(engineStart.Add(step.at))
+		if step.key == 0 {
+			engine.Tick(emit)
+			continue
+		}
+		engine.Transform(buttonInputEvent(step.key, int(step.value)), emit)
+	}
+	return sent
+}
+
+func TestKeyEngineTapHold(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		permissiveHold bool
+		steps          []engineStep
+		sent           []string
+	}{
+		{
+			name:  "tap",
+			steps: []engineStep{{0, KEY_CAPSLOCK, 1}, {100 * time.Millisecond, KEY_CAPSLOCK, 0}},
+			sent:  []string{"KEY_ESC 1", "KEY_ESC 0"},
+		},
+		{
+			name:  "undecided within the tapping term",
+			steps: []engineStep{{0, KEY_CAPSLOCK, 1}, tick(199 * time.Millisecond)},
+			sent:  nil,
+		},
+		{
+			name:  "hold once the tapping term ends",
+			steps: []engineStep{{0, KEY_CAPSLOCK, 1}, tick(200 * time.Millisecond), {300 * time.Millisecond, KEY_CAPSLOCK, 0}},
+			sent:  []string{"KEY_LEFTCTRL 1", "KEY_LEFTCTRL 0"},
+		},
+		{
+			name: "hold decided by a later key",
+			steps: []engineStep{
+				{0, KEY_CAPSLOCK, 1}, {250 * time.Millisecond, KEY_A, 1},
+				{260 * time.Millisecond, KEY_A, 0}, {270 * time.Millisecond, KEY_CAPSLOCK, 0},
+			},
+			sent: []string{"KEY_LEFTCTRL 1", "KEY_A 1", "KEY_A 0", "KEY_LEFTCTRL 0"},
+		},
+		{
+			name: "key tapped inside the term is a tap",
+			steps: []engineStep{
+				{0, KEY_CAPSLOCK, 1}, {50 * time.Millisecond, KEY_A, 1},
+				{80 * time.Millisecond, KEY_A, 0}, {120 * time.Millisecond, KEY_CAPSLOCK, 0},
+			},
+			sent: []string{"KEY_ESC 1", "KEY_A 1", "KEY_A 0", "KEY_ESC 0"},
+		},
+		{
+			name:           "permissive hold on a key tapped inside the term",
+			permissiveHold: true,
+			steps: []engineStep{
+				{0, KEY_CAPSLOCK, 1}, {50 * time.Millisecond, KEY_A, 1},
+				{80 * time.Millisecond, KEY_A, 0}, {120 * time.Millisecond, KEY_CAPSLOCK, 0},
+			},
+			sent: []string{"KEY_LEFTCTRL 1", "KEY_A 1", "KEY_A 0", "KEY_LEFTCTRL 0"},
+		},
+		{
+			name:           "permissive hold waits for the other key to be released",
+			permissiveHold: true,
+			steps: []engineStep{
+				{0, KEY_CAPSLOCK, 1}, {50 * time.Millisecond, KEY_A, 1},
+				{80 * time.Millisecond, KEY_CAPSLOCK, 0}, {90 * time.Millisecond, KEY_A, 0},
+			},
+			sent: []string{"KEY_ESC 1", "KEY_A 1", "KEY_ESC 0", "KEY_A 0"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewKeyEngine(Layer{KEY_CAPSLOCK: ModTap(KEY_LEFTCTRL, KEY_ESC)})
+			engine.PermissiveHold = test.permissiveHold
+			if sent := runEngine(t, engine, test.steps); !reflect.DeepEqual(sent, test.sent) {
+				t.Errorf("sent %q, want %q", sent, test.sent)
+			}
+		})
+	}
+}
+
+func TestKeyEngineCombos(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		steps []engineStep
+		sent  []string
+	}{
+		{
+			name: "pressed within the combo term",
+			steps: []engineStep{
+				{0, KEY_J, 1}, {20 * time.Millisecond, KEY_K, 1},
+				{100 * time.Millisecond, KEY_J, 0}, {110 * time.Millisecond, KEY_K, 0},
+			},
+			sent: []string{"KEY_ESC 1", "KEY_ESC 0"},
+		},
+		{
+			name:  "waiting within the combo term",
+			steps: []engineStep{{0, KEY_J, 1}, tick(49 * time.Millisecond)},
+			sent:  nil,
+		},
+		{
+			name:  "timed out alone",
+			steps: []engineStep{{0, KEY_J, 1}, tick(50 * time.Millisecond), {80 * time.Millisecond, KEY_J, 0}},
+			sent:  []string{"KEY_J 1", "KEY_J 0"},
+		},
+		{
+			name: "second key after the combo term",
+			steps: []engineStep{
+				{0, KEY_J, 1}, {70 * time.Millisecond, KEY_K, 1}, tick(120 * time.Millisecond),
+				{130 * time.Millisecond, KEY_K, 0}, {140 * time.Millisecond, KEY_J, 0},
+			},
+			sent: []string{"KEY_J 1", "KEY_K 1", "KEY_K 0", "KEY_J 0"},
+		},
+		{
+			name:  "interrupted by another key",
+			steps: []engineStep{{0, KEY_J, 1}, {10 * time.Millisecond, KEY_A, 1}},
+			sent:  []string{"KEY_J 1", "KEY_A 1"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewKeyEngine()
+			engine.Combos = []Combo{{Keys: []EventCode{KEY_J, KEY_K}, Output: KEY_ESC}}
+			if sent := runEngine(t, engine, test.steps); !reflect.DeepEqual(sent, test.sent) {
+				t.Errorf("sent %q, want %q", sent, test.sent)
+			}
+		})
+	}
+}
+
+func TestKeyEngineOneShot(t *testing.T) {
+	tapShift := []engineStep{{0, KEY_CAPSLOCK, 1}, {10 * time.Millisecond, KEY_CAPSLOCK, 0}}
+	for _, test := range []struct {
+		name    string
+		timeout time.Duration
+		steps   []engineStep
+		sent    []string
+	}{
+		{
+			name:  "applied to the next key",
+			steps: append(tapShift, engineStep{100 * time.Millisecond, KEY_A, 1}, engineStep{120 * time.Millisecond, KEY_A, 0}),
+			sent:  []string{"KEY_LEFTSHIFT 1", "KEY_A 1", "KEY_A 0", "KEY_LEFTSHIFT 0"},
+		},
+		{
+			name:    "applied before the timeout",
+			timeout: 500 * time.Millisecond,
+			steps:   append(tapShift, engineStep{509 * time.Millisecond, KEY_A, 1}, engineStep{520 * time.Millisecond, KEY_A, 0}),
+			sent:    []string{"KEY_LEFTSHIFT 1", "KEY_A 1", "KEY_A 0", "KEY_LEFTSHIFT 0"},
+		},
+		{
+			name:    "expired on tick",
+			timeout: 500 * time.Millisecond,
+			steps:   append(tapShift, tick(510*time.Millisecond), engineStep{600 * time.Millisecond, KEY_A, 1}),
+			sent:    []string{"KEY_LEFTSHIFT 1", "KEY_LEFTSHIFT 0", "KEY_A 1"},
+		},
+		{
+			name:    "expired before the next key",
+			timeout: 500 * time.Millisecond,
+			steps:   append(tapShift, engineStep{600 * time.Millisecond, KEY_A, 1}),
+			sent:    []string{"KEY_LEFTSHIFT 1", "KEY_LEFTSHIFT 0", "KEY_A 1"},
+		},
+		{
+			name:  "held with another key",
+			steps: []engineStep{{0, KEY_CAPSLOCK, 1}, {10 * time.Millisecond, KEY_A, 1}, {20 * time.Millisecond, KEY_A, 0}, {30 * time.Millisecond, KEY_CAPSLOCK, 0}, {40 * time.Millisecond, KEY_B, 1}},
+			sent:  []string{"KEY_LEFTSHIFT 1", "KEY_A 1", "KEY_A 0", "KEY_LEFTSHIFT 0", "KEY_B 1"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewKeyEngine(Layer{KEY_CAPSLOCK: OneShotMod(KEY_LEFTSHIFT)})
+			engine.OneShotTimeout = test.timeout
+			if sent := runEngine(t, engine, test.steps); !reflect.DeepEqual(sent, test.sent) {
+				t.Errorf("sent %q, want %q", sent, test.sent)
+			}
+		})
+	}
+}
+
+func TestKeyEngineDeadline(t *testing.T) {
+	engine := NewKeyEngine(Layer{KEY_CAPSLOCK: OneShotMod(KEY_LEFTSHIFT), KEY_TAB: ModTap(KEY_LEFTALT, KEY_TAB)})
+	engine.OneShotTimeout = time.Second
+	runEngine(t, engine, []engineStep{{0, KEY_CAPSLOCK, 1}, {10 * time.Millisecond, KEY_CAPSLOCK, 0}})
+	if deadline, ok := engine.Deadline(); !ok || !deadline.Equal(engineStart.Add(10*time.Millisecond+time.Second)) {
+		t.Errorf("one-shot deadline %v %v", deadline, ok)
+	}
+	engine.Clock.(*FakeClock).Set(engineStart.Add(20 * time.Millisecond))
+	engine.Transform(buttonInputEvent(KEY_TAB, 1), func(InputEvent) {})
+	if deadline, ok := engine.Deadline(); !ok || !deadline.Equal(engineStart.Add(20*time.Millisecond+DefaultTappingTerm)) {
+		t.Errorf("tapping term deadline %v %v", deadline, ok)
+	}
+}
+
+func TestTickTransformsUsesEngineClock(t *testing.T) {
+	// NOTE: The fake clock is years behind the system one, which would have
+	// decided the key long ago.
+	engine := NewKeyEngine(Layer{KEY_CAPSLOCK: ModTap(KEY_LEFTCTRL, KEY_ESC)})
+	clock := NewFakeClock(engineStart)
+	engine.Clock = clock
+	var sent []InputEvent
+	inputs := chainTransforms([]Transform{engine}, func(event InputEvent) { sent = append(sent, event) })
+	inputs[0](buttonInputEvent(KEY_CAPSLOCK, 1))
+	clock.Advance(150 * time.Millisecond)
+	wait, ok := tickTransforms([]Transform{engine}, inputs)
+	if !ok || wait != 50*time.Millisecond || len(sent) != 0 {
+		t.Fatalf("waiting %v %v with %d events sent, want 50ms and none", wait, ok, len(sent))
+	}
+	clock.Advance(wait)
+	if _, ok := tickTransforms([]Transform{engine}, inputs); ok || len(sent) != 2 {
+		t.Fatalf("still waiting %v with %d events sent, want the hold and its sync", ok, len(sent))
+	}
+}
+
+func TestKeyEngineZeroValue(t *testing.T) {
+	var sent []InputEvent
+	emit := func(event InputEvent) {
+		if event.Type == EV_KEY.Code() {
+			sent = append(sent, event)
+		}
+	}
+	engine := &KeyEngine{}
+	engine.Tick(emit)
+	engine.Transform(buttonInputEvent(KEY_A, 1), emit)
+	engine.Transform(buttonInputEvent(KEY_A, 0), emit)
+	if len(sent) != 2 || engine.Layer(1) {
+		t.Errorf("sent %+v through an engine without layers", sent)
+	}
+
+	engine = &KeyEngine{Layers: []Layer{{KEY_CAPSLOCK: ModTap(KEY_LEFTCTRL, KEY_ESC)}}}
+	steps := []engineStep{{0, KEY_CAPSLOCK, 1}, tick(DefaultTappingTerm - time.Millisecond), tick(DefaultTappingTerm), {300 * time.Millisecond, KEY_CAPSLOCK, 0}}
+	want := []string{"KEY_LEFTCTRL 1", "KEY_LEFTCTRL 0"}
+	if sent := runEngine(t, engine, steps); !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent %q, want %q with the default tapping term", sent, want)
+	}
+}
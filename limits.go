@@ -0,0 +1,174 @@
+package uinput
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LimitPolicy decides what happens to a frame going over the device Limits.
+type LimitPolicy int
+
+const (
+	// BlockOnLimit waits until the rate allows the frame. Frames over
+	// MaxFrameSize can never fit and fail as with FailOnLimit.
+	BlockOnLimit LimitPolicy = iota
+	// DropOnLimit leaves the frame out without an error.
+	DropOnLimit
+	// FailOnLimit fails the frame with ErrLimitExceeded.
+	FailOnLimit
+)
+
+// ErrLimitExceeded is returned for frames going over the device Limits under
+// FailOnLimit, wrapped by the methods writing them.
+var ErrLimitExceeded = errors.New("[error] device limit exceeded")
+
+// Limits guard the desktop against automation gone wrong, such as a loop
+// flooding it with key events. Zero values leave a limit off. Frames only
+// releasing keys and buttons held, as written by ReleaseKey, ReleaseAll or
+// Disconnect, are never limited so a limit cannot leave a key stuck.
+type Limits struct {
+	// EventsPerSecond bounds the events written, SYN_REPORT aside, allowing
+	// bursts of up to a second worth of events.
+	EventsPerSecond int
+	// MaxFrameSize bounds the events of a single frame, SYN_REPORT aside.
+	MaxFrameSize int
+	// MaxHold is the longest a key or button stays down before the device
+	// releases it by itself, measured on the device Clock.
+	MaxHold time.Duration
+	Policy  LimitPolicy
+}
+
+// LimitCounters count the frames the device Limits stopped.
+type LimitCounters struct {
+	Blocked uint64 // frames delayed by EventsPerSecond
+	Dropped uint64 // frames left out under DropOnLimit
+	Failed  uint64 // frames failed with ErrLimitExceeded
+	// HoldReleases are the keys and buttons released for going over MaxHold
+	HoldReleases uint64
+}
+
+// limiter is the state of the device Limits, guarded by the device mutex.
+type limiter struct {
+	tokens   float64
+	refilled time.Time
+	counters LimitCounters
+	// presses numbers the presses of each key, so a MaxHold timer only
+	// releases the press it was started for.
+	presses map[EventCode]uint64
+	// holds are the MaxHold timers of the keys held, stopped on release.
+	holds map[EventCode]Timer
+}
+
+// errDropped tells writeFrameLocked to leave a frame out without an error.
+var errDropped = errors.New("dropped")
+
+// limit applies the device Limits to a frame of count events, returning how
+// long to wait before writing it under BlockOnLimit. The events are taken
+// from the rate at once, so the writers coming next wait their turn after it.
+func (self *Device) limit(count int) (time.Duration, error) {
+	limits := self.Limits
+	if limits.MaxFrameSize > 0 && count > limits.MaxFrameSize {
+		return 0, self.overLimit(fmt.Errorf("%w: %d events in a frame, at most %d", ErrLimitExceeded, count, limits.MaxFrameSize))
+	}
+	if limits.EventsPerSecond <= 0 {
+		return 0, nil
+	}
+	rate := float64(limits.EventsPerSecond)
+	now := self.clock().Now()
+	if self.limiter.refilled.IsZero() {
+		self.limiter.tokens = rate
+	} else {
+		self.limiter.tokens += now.Sub(self.limiter.refilled).Seconds() * rate
+		if self.limiter.tokens > rate {
+			self.limiter.tokens = rate
+		}
+	}
+	self.limiter.refilled = now
+	if self.limiter.tokens >= float64(count) {
+		self.limiter.tokens -= float64(count)
+		return 0, nil
+	}
+	if limits.Policy != BlockOnLimit {
+		return 0, self.overLimit(fmt.Errorf("%w: over %d events per second", ErrLimitExceeded, limits.EventsPerSecond))
+	}
+	self.limiter.counters.Blocked++
+	wait := time.Duration((float64(count) - self.limiter.tokens) / rate * float64(time.Second))
+	self.limiter.tokens -= float64(count)
+	return wait, nil
+}
+
+func (self *Device) overLimit(err error) error {
+	if self.Limits.Policy == DropOnLimit {
+		self.limiter.counters.Dropped++
+		return errDropped
+	}
+	self.limiter.counters.Failed++
+	return err
+}
+
+// releasesHeld tells whether events only release keys and buttons held, along
+// with their scan codes.
+func (self *Device) releasesHeld(events []InputEvent) bool {
+	released := false
+	for _, event := range events {
+		switch {
+		case event.Type == miscEvent.UInt16() && event.Code == uint16(MSC_SCAN):
+		case event.Type == EV_KEY.Code() && event.Value == int32(KeyReleased.Code()) && self.state.IsPressed(EventCode(event.Code)):
+			released = true
+		default:
+			return false
+		}
+	}
+	return released
+}
+
+// watchHolds starts a MaxHold timer for the keys and buttons a frame pressed,
+// and stops the timers of those it released.
+func (self *Device) watchHolds(events []InputEvent) {
+	for _, event := range events {
+		if event.Type != EV_KEY.Code() {
+			continue
+		}
+		key := EventCode(event.Code)
+		if timer, ok := self.limiter.holds[key]; ok {
+			timer.Stop()
+			delete(self.limiter.holds, key)
+		}
+		if self.Limits.MaxHold <= 0 || event.Value != int32(KeyPressed.Code()) {
+			continue
+		}
+		if self.limiter.presses == nil {
+			self.limiter.presses = make(map[EventCode]uint64)
+			self.limiter.holds = make(map[EventCode]Timer)
+		}
+		self.limiter.presses[key]++
+		press := self.limiter.presses[key]
+		self.limiter.holds[key] = self.clock().AfterFunc(self.Limits.MaxHold, func() {
+			self.mutex.Lock()
+			defer self.mutex.Unlock()
+			if self.FD == nil || self.limiter.presses[key] != press || !self.state.IsPressed(key) {
+				return
+			}
+			events := append(self.scanCodeEvents(key), buttonInputEvent(key, KeyReleased.Code()))
+			if self.writeEventsLocked(events) == nil {
+				self.limiter.counters.HoldReleases++
+			}
+		})
+	}
+}
+
+// stopHolds stops every MaxHold timer left.
+func (self *Device) stopHolds() {
+	for key, timer := range self.limiter.holds {
+		timer.Stop()
+		delete(self.limiter.holds, key)
+	}
+}
+
+// LimitCounters returns how often the device Limits stopped a frame.
+func (self *Device) LimitCounters() LimitCounters {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.limiter.counters
+}
@@ -0,0 +1,172 @@
+package uinput
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// gatedClock is a FakeClock whose Sleep blocks until the test lets it go.
+type gatedClock struct {
+	*FakeClock
+	sleeping chan time.Duration
+	wake     chan struct{}
+}
+
+func (self *gatedClock) Sleep(duration time.Duration) {
+	self.sleeping <- duration
+	<-self.wake
+	self.Advance(duration)
+}
+
+func TestMaxHoldOnDeviceClock(t *testing.T) {
+	device, clock, events := recordingDevice(t, Keyboard)
+	device.Limits = Limits{MaxHold: time.Second}
+	if err := device.PressKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	if err := device.ReleaseKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(600 * time.Millisecond)
+	if err := device.PressKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: The timer of the first press finds the key pressed again and
+	// leaves it to the timer of the second.
+	clock.Advance(999 * time.Millisecond)
+	if written := events(); len(written) != 3 {
+		t.Fatalf("released early, wrote %q", written)
+	}
+	clock.Advance(time.Millisecond)
+	want := []string{"+0s KEY_A pressed", "+0s KEY_A released", "+600ms KEY_A pressed", "+1.6s KEY_A released"}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+	if counters := device.LimitCounters(); counters.HoldReleases != 1 {
+		t.Errorf("counted %d hold releases, want 1", counters.HoldReleases)
+	}
+}
+
+func TestBlockOnLimitWaitsOnDeviceClock(t *testing.T) {
+	device, clock, _ := recordingDevice(t, Keyboard)
+	device.Limits = Limits{EventsPerSecond: 2}
+	for i := 0; i < 3; i++ {
+		if err := device.SyncEvents(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := clock.Now().Sub(clockStart); elapsed != 500*time.Millisecond {
+		t.Errorf("waited %v, want 500ms", elapsed)
+	}
+	if counters := device.LimitCounters(); counters.Blocked != 1 {
+		t.Errorf("counted %d blocked frames, want 1", counters.Blocked)
+	}
+}
+
+func TestBlockOnLimitReleasesMutex(t *testing.T) {
+	device, err := Keyboard.DryRun("limited keyboard", Trace{Writer: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &gatedClock{FakeClock: NewFakeClock(clockStart), sleeping: make(chan time.Duration), wake: make(chan struct{})}
+	device.Clock = clock
+	device.Limits = Limits{EventsPerSecond: 1}
+	if err := device.PressKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	blocked := make(chan error)
+	go func() { blocked <- device.PressKey(KEY_B) }()
+	if wait := <-clock.sleeping; wait != time.Second {
+		t.Errorf("waiting %v, want 1s", wait)
+	}
+	released := make(chan error)
+	go func() {
+		if err := device.ReleaseAll(); err != nil {
+			released <- err
+			return
+		}
+		_, err := device.Disconnect()
+		released <- err
+	}()
+	select {
+	case err := <-released:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReleaseAll and Disconnect waited on the blocked frame")
+	}
+	close(clock.wake)
+	if err := <-blocked; !errors.Is(err, ErrDisconnected) {
+		t.Errorf("blocked frame failed with %v, want ErrDisconnected", err)
+	}
+}
+
+func TestLimitsLetReleasesThrough(t *testing.T) {
+	device, _, events := recordingDevice(t, Keyboard)
+	device.Limits = Limits{EventsPerSecond: 1, Policy: DropOnLimit}
+	for _, key := range []EventCode{KEY_A, KEY_B} {
+		if err := device.PressKey(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := device.ReleaseKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: Releasing a key not held is limited as any other frame.
+	if err := device.ReleaseKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"+0s KEY_A pressed", "+0s KEY_A released"}
+	if written := events(); !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+	if counters := device.LimitCounters(); counters.Dropped != 2 {
+		t.Errorf("counted %d dropped frames, want 2", counters.Dropped)
+	}
+}
+
+// timerClock is a FakeClock keeping the timers it starts.
+type timerClock struct {
+	*FakeClock
+	timers []Timer
+}
+
+func (self *timerClock) AfterFunc(duration time.Duration, f func()) Timer {
+	timer := self.FakeClock.AfterFunc(duration, f)
+	self.timers = append(self.timers, timer)
+	return timer
+}
+
+func TestMaxHoldTimersStopped(t *testing.T) {
+	device, err := Keyboard.DryRun("held keyboard", Trace{Writer: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &timerClock{FakeClock: NewFakeClock(clockStart)}
+	device.Clock = clock
+	device.Limits = Limits{MaxHold: time.Second}
+	if err := device.PressKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	if err := device.ReleaseKey(KEY_A); err != nil {
+		t.Fatal(err)
+	}
+	if err := device.PressKey(KEY_B); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := device.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	for n, timer := range clock.timers {
+		if timer.Stop() {
+			t.Errorf("timer %d still running", n)
+		}
+	}
+	if len(clock.timers) != 2 {
+		t.Errorf("started %d timers, want 2", len(clock.timers))
+	}
+}
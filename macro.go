@@ -0,0 +1,525 @@
+package uinput
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Macros automate a keyboard and a pointer from a small text language, one
+// command per line (or separated by ";"), "#" starting a comment:
+//
+//	type "hello world"     # types text on the QWERTY layout
+//	key ctrl+s             # taps an accelerator, as parseAccel reads it
+//	press shift            # holds keys down ...
+//	release shift          # ... until released
+//	click left             # clicks a button, optionally several times
+//	click left 2
+//	move 100,200           # moves the pointer
+//	move 100,200 over 300ms
+//	wait 500ms
+//	repeat 3 {
+//		key tab
+//	}
+//
+// Moves are relative on a mouse and absolute on tablets and touchpads, as for
+// Drag.
+
+// MacroError locates a syntax or validation error in a macro, lines and
+// columns counting from 1.
+type MacroError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (self *MacroError) Error() string {
+	return fmt.Sprintf("[error] macro line %d column %d: %v", self.Line, self.Column, self.Message)
+}
+
+// MacroErrors lists every error found validating a macro.
+type MacroErrors []*MacroError
+
+func (self MacroErrors) Error() string {
+	messages := make([]string, 0, len(self))
+	for _, err := range self {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+type macroTokenKind int
+
+const (
+	macroWord macroTokenKind = iota
+	macroString
+	macroComma
+	macroOpen
+	macroClose
+	macroEnd // end of a command: a newline or ";"
+	macroEOF
+)
+
+type macroToken struct {
+	kind         macroTokenKind
+	text         string
+	line, column int
+}
+
+func (self macroToken) errorf(format string, args ...interface{}) *MacroError {
+	return &MacroError{Line: self.line, Column: self.column, Message: fmt.Sprintf(format, args...)}
+}
+
+// macroButtons names the buttons clicks accept.
+var macroButtons = map[string]ButtonType{
+	"left":    LeftButton,
+	"right":   RightButton,
+	"middle":  MiddleButton,
+	"side":    SideButton,
+	"extra":   ExtraButton,
+	"forward": ForwardButton,
+	"back":    BackButton,
+	"task":    TaskButton,
+}
+
+// macroStepDelay is the interval between the steps of a timed move.
+const macroStepDelay = 10 * time.Millisecond
+
+func lexMacro(source string) ([]macroToken, error) {
+	var tokens []macroToken
+	runes := []rune(source)
+	line, column := 1, 1
+	for n := 0; n < len(runes); {
+		r, start := runes[n], macroToken{line: line, column: column}
+		advance := func(count int) {
+			n, column = n+count, column+count
+		}
+		switch {
+		case r == '\n':
+			tokens = append(tokens, macroToken{kind: macroEnd, text: "\n", line: line, column: column})
+			n, line, column = n+1, line+1, 1
+		case r == '#':
+			for n < len(runes) && runes[n] != '\n' {
+				advance(1)
+			}
+		case unicode.IsSpace(r):
+			advance(1)
+		case r == ';' || r == ',' || r == '{' || r == '}':
+			start.kind, start.text = map[rune]macroTokenKind{';': macroEnd, ',': macroComma, '{': macroOpen, '}': macroClose}[r], string(r)
+			tokens = append(tokens, start)
+			advance(1)
+		case r == '"':
+			end := n + 1
+			for ; end < len(runes) && runes[end] != '"' && runes[end] != '\n'; end++ {
+				if runes[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(runes) || runes[end] != '"' {
+				return nil, start.errorf("unterminated string")
+			}
+			text, err := strconv.Unquote(string(runes[n : end+1]))
+			if err != nil {
+				return nil, start.errorf("invalid string: %v", err)
+			}
+			start.kind, start.text = macroString, text
+			tokens = append(tokens, start)
+			advance(end + 1 - n)
+		default:
+			end := n
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(";,{}\"#", runes[end]) {
+				end++
+			}
+			start.kind, start.text = macroWord, string(runes[n:end])
+			tokens = append(tokens, start)
+			advance(end - n)
+		}
+	}
+	return append(tokens, macroToken{kind: macroEOF, line: line, column: column}), nil
+}
+
+// macroStatement is one command of a macro, with its arguments as written
+// until validation decodes them.
+type macroStatement struct {
+	command macroToken
+	args    []macroToken
+	body    []*macroStatement
+
+	text     string
+	keys     []EventCode
+	button   ButtonType
+	count    int
+	target   Point
+	duration time.Duration
+}
+
+// Macro is a parsed macro, ready to be validated and run.
+type Macro struct {
+	statements []*macroStatement
+	validated  bool
+}
+
+type macroParser struct {
+	tokens []macroToken
+	next   int
+}
+
+func (self *macroParser) peek() macroToken {
+	return self.tokens[self.next]
+}
+
+func (self *macroParser) take() macroToken {
+	token := self.tokens[self.next]
+	if token.kind != macroEOF {
+		self.next++
+	}
+	return token
+}
+
+// ParseMacro parses the syntax of a macro, leaving the meaning of commands and
+// their arguments to Validate.
+func ParseMacro(source string) (*Macro, error) {
+	tokens, err := lexMacro(source)
+	if err != nil {
+		return nil, err
+	}
+	parser := &macroParser{tokens: tokens}
+	statements, err := parser.statements(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Macro{statements: statements}, nil
+}
+
+// statements parses commands up to the end of the macro, or up to the closing
+// brace of the block opened by open.
+func (self *macroParser) statements(open *macroToken) (statements []*macroStatement, err error) {
+	for {
+		token := self.take()
+		switch token.kind {
+		case macroEnd:
+			continue
+		case macroEOF:
+			if open != nil {
+				return nil, open.errorf("missing } closing this block")
+			}
+			return statements, nil
+		case macroClose:
+			if open == nil {
+				return nil, token.errorf("unexpected }")
+			}
+			return statements, nil
+		case macroWord:
+		default:
+			return nil, token.errorf("expected a command, found %q", token.text)
+		}
+		statement := &macroStatement{command: token}
+		for kind := self.peek().kind; kind != macroEnd && kind != macroEOF && kind != macroClose && kind != macroOpen; kind = self.peek().kind {
+			statement.args = append(statement.args, self.take())
+		}
+		if self.peek().kind == macroOpen {
+			brace := self.take()
+			if statement.body, err = self.statements(&brace); err != nil {
+				return nil, err
+			}
+			if statement.body == nil {
+				statement.body = []*macroStatement{}
+			}
+		}
+		statements = append(statements, statement)
+	}
+}
+
+// Validate checks every command and its arguments, reporting all errors found
+// as MacroErrors.
+func (self *Macro) Validate() error {
+	var errs MacroErrors
+	validateMacro(self.statements, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	self.validated = true
+	return nil
+}
+
+func validateMacro(statements []*macroStatement, errs *MacroErrors) {
+	for _, statement := range statements {
+		if err := statement.validate(); err != nil {
+			*errs = append(*errs, err)
+		}
+		if statement.body != nil {
+			validateMacro(statement.body, errs)
+		}
+	}
+}
+
+func (self *macroStatement) validate() *MacroError {
+	command, args := self.command, self.args
+	if self.body != nil && command.text != "repeat" {
+		return command.errorf("%v does not take a block", command.text)
+	}
+	expect := func(min, max int, usage string) *MacroError {
+		if len(args) < min || len(args) > max {
+			return command.errorf("usage: %v", usage)
+		}
+		return nil
+	}
+	switch command.text {
+	case "type":
+		if err := expect(1, 1, `type "text"`); err != nil {
+			return err
+		}
+		if args[0].kind != macroString {
+			return args[0].errorf("text to type must be quoted")
+		}
+		for _, r := range args[0].text {
+			if _, _, err := runeKey(r); err != nil {
+				return args[0].errorf("cannot type %q", r)
+			}
+		}
+		self.text = args[0].text
+	case "key", "press", "release":
+		if err := expect(1, 1, command.text+" ctrl+s"); err != nil {
+			return err
+		}
+		keys, err := parseAccel(args[0].text)
+		if err != nil || args[0].kind != macroWord {
+			return args[0].errorf("invalid keys %q", args[0].text)
+		}
+		self.keys = keys
+	case "click":
+		if err := expect(1, 2, "click left [count]"); err != nil {
+			return err
+		}
+		button, ok := macroButtons[strings.ToLower(args[0].text)]
+		if !ok {
+			return args[0].errorf("unknown button %q", args[0].text)
+		}
+		self.button, self.count = button, 1
+		if len(args) == 2 {
+			count, err := macroCount(args[1])
+			if err != nil {
+				return err
+			}
+			self.count = count
+		}
+	case "move":
+		if (len(args) != 3 && len(args) != 5) || args[1].kind != macroComma || (len(args) == 5 && args[3].text != "over") {
+			return command.errorf("usage: move x,y [over duration]")
+		}
+		for n, coordinate := range []*int32{&self.target.X, &self.target.Y} {
+			value, err := strconv.ParseInt(args[2*n].text, 10, 32)
+			if err != nil || args[2*n].kind != macroWord {
+				return args[2*n].errorf("invalid coordinate %q", args[2*n].text)
+			}
+			*coordinate = int32(value)
+		}
+		if len(args) == 5 {
+			duration, err := macroDuration(args[4])
+			if err != nil {
+				return err
+			}
+			self.duration = duration
+		}
+	case "wait":
+		if err := expect(1, 1, "wait 500ms"); err != nil {
+			return err
+		}
+		duration, err := macroDuration(args[0])
+		if err != nil {
+			return err
+		}
+		self.duration = duration
+	case "repeat":
+		if err := expect(1, 1, "repeat count { ... }"); err != nil {
+			return err
+		}
+		count, err := macroCount(args[0])
+		if err != nil {
+			return err
+		}
+		if self.body == nil {
+			return command.errorf("repeat needs a { ... } block")
+		}
+		self.count = count
+	default:
+		return command.errorf("unknown command %q", command.text)
+	}
+	return nil
+}
+
+func macroCount(token macroToken) (int, *MacroError) {
+	count, err := strconv.Atoi(token.text)
+	if err != nil || count < 1 || token.kind != macroWord {
+		return 0, token.errorf("invalid count %q", token.text)
+	}
+	return count, nil
+}
+
+func macroDuration(token macroToken) (time.Duration, *MacroError) {
+	duration, err := time.ParseDuration(token.text)
+	if err != nil || duration < 0 || token.kind != macroWord {
+		return 0, token.errorf("invalid duration %q, e.g. 500ms or 1.5s", token.text)
+	}
+	return duration, nil
+}
+
+// macroRun is the state of a running macro.
+type macroRun struct {
+	keyboard, pointer *Device
+	// pressed are the keys held by press commands, released when the macro
+	// ends whichever way.
+	pressed []EventCode
+	// at is the last absolute position moved to.
+	at *Point
+}
+
+// Run validates the macro, unless already done, then runs it on keyboard and
+// pointer until it ends or ctx is done. Either device may be nil when the
+// macro does not use it. Keys still held by press commands are released when
+// the macro ends.
+func (self *Macro) Run(ctx context.Context, keyboard, pointer *Device) (err error) {
+	if !self.validated {
+		if err := self.Validate(); err != nil {
+			return err
+		}
+	}
+	run := &macroRun{keyboard: keyboard, pointer: pointer}
+	defer func() {
+		for n := len(run.pressed) - 1; n >= 0; n-- {
+			if releaseErr := run.keyboard.ReleaseKey(run.pressed[n]); err == nil {
+				err = releaseErr
+			}
+		}
+	}()
+	return run.statements(ctx, self.statements)
+}
+
+func (self *macroRun) statements(ctx context.Context, statements []*macroStatement) error {
+	for _, statement := range statements {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := self.statement(ctx, statement); err != nil {
+			if _, ok := err.(*MacroError); ok || err == ctx.Err() {
+				return err
+			}
+			return statement.command.errorf("%v", err)
+		}
+	}
+	return nil
+}
+
+func (self *macroRun) statement(ctx context.Context, statement *macroStatement) error {
+	switch statement.command.text {
+	case "type", "key", "press", "release":
+		if self.keyboard == nil {
+			return statement.command.errorf("no keyboard to %v on", statement.command.text)
+		}
+	case "click", "move":
+		if self.pointer == nil {
+			return statement.command.errorf("no pointer to %v", statement.command.text)
+		}
+	}
+	switch statement.command.text {
+	case "type":
+		return self.keyboard.TypeText(statement.text)
+	case "key":
+		return self.keyboard.accel(statement.keys)
+	case "press":
+		for _, key := range statement.keys {
+			if err := self.keyboard.PressKey(key); err != nil {
+				return err
+			}
+			self.pressed = append(self.pressed, key)
+		}
+	case "release":
+		for n := len(statement.keys) - 1; n >= 0; n-- {
+			if err := self.keyboard.ReleaseKey(statement.keys[n]); err != nil {
+				return err
+			}
+			self.unpress(statement.keys[n])
+		}
+	case "click":
+		return self.pointer.MultiClick(statement.button, statement.count)
+	case "move":
+		return self.move(ctx, statement.target, statement.duration)
+	case "wait":
+		return sleepContext(ctx, self.clock(), statement.duration)
+	case "repeat":
+		for n := 0; n < statement.count; n++ {
+			if err := self.statements(ctx, statement.body); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// clock times the waits of the macro on the clock of its keyboard, or of its
+// pointer without one.
+func (self *macroRun) clock() Clock {
+	if self.keyboard != nil {
+		return self.keyboard.clock()
+	}
+	if self.pointer != nil {
+		return self.pointer.clock()
+	}
+	return SystemClock
+}
+
+func (self *macroRun) unpress(key EventCode) {
+	for n, pressed := range self.pressed {
+		if pressed == key {
+			self.pressed = append(self.pressed[:n], self.pressed[n+1:]...)
+			return
+		}
+	}
+}
+
+// move moves the pointer in steps spread over duration: by target on a mouse,
+// and to target otherwise, starting from the last position moved to.
+func (self *macroRun) move(ctx context.Context, target Point, duration time.Duration) error {
+	steps := int64(duration / macroStepDelay)
+	if steps < 1 {
+		steps = 1
+	}
+	relative := self.pointer.Type == Mouse
+	from := Point{}
+	if !relative {
+		if self.at == nil {
+			// NOTE: The starting point of the first absolute move is
+			// unknown, so it jumps straight to the target.
+			steps = 1
+		} else {
+			from = *self.at
+		}
+		self.at = &target
+	}
+	previous := from
+	for step := int64(1); step <= steps; step++ {
+		next := Point{
+			X: from.X + int32(int64(target.X-from.X)*step/steps),
+			Y: from.Y + int32(int64(target.Y-from.Y)*step/steps),
+		}
+		var err error
+		if relative {
+			err = self.pointer.RelativeMove(Point{X: next.X - previous.X, Y: next.Y - previous.Y})
+		} else {
+			err = self.pointer.AbsoluteMoveTo(next)
+		}
+		if err != nil {
+			return err
+		}
+		previous = next
+		if step < steps {
+			if err := sleepContext(ctx, self.clock(), duration/time.Duration(steps)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
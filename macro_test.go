@@ -0,0 +1,145 @@
+package uinput
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMacroErrors(t *testing.T) {
+	for _, test := range []struct {
+		source string
+		err    string
+	}{
+		{`type "hello`, "[error] macro line 1 column 6: unterminated string"},
+		{"type \"hel\nlo\"", "[error] macro line 1 column 6: unterminated string"},
+		{`type "\q"`, `[error] macro line 1 column 6: invalid string: invalid syntax`},
+		{"key a\n}", "[error] macro line 2 column 1: unexpected }"},
+		{"repeat 2 {\n  key a\n", "[error] macro line 1 column 10: missing } closing this block"},
+		{"key a; , b", `[error] macro line 1 column 8: expected a command, found ","`},
+		{`"text"`, `[error] macro line 1 column 1: expected a command, found "text"`},
+	} {
+		_, err := ParseMacro(test.source)
+		var macroErr *MacroError
+		if !errors.As(err, &macroErr) || err.Error() != test.err {
+			t.Errorf("%q: parsed with %v, want %v", test.source, err, test.err)
+		}
+	}
+}
+
+func TestMacroValidate(t *testing.T) {
+	for _, test := range []struct {
+		source string
+		errs   []string
+	}{
+		{source: "type \"hello, world\"\nkey ctrl+shift+s; press alt\nrelease alt\n# done"},
+		{source: "click left; click right 3\nmove 100,-200\nmove 5,5 over 300ms\nwait 1.5s"},
+		{source: "repeat 3 {\n  repeat 2 { key tab }\n}\nrepeat 1 {}"},
+		{source: "dance", errs: []string{"line 1 column 1: unknown command \"dance\""}},
+		{source: "type hello", errs: []string{"line 1 column 6: text to type must be quoted"}},
+		{source: `type "a" "b"`, errs: []string{`line 1 column 1: usage: type "text"`}},
+		{source: `type "€"`, errs: []string{`line 1 column 6: cannot type '€'`}},
+		{source: "key ctrl+nope", errs: []string{`line 1 column 5: invalid keys "ctrl+nope"`}},
+		{source: `key "a"`, errs: []string{`line 1 column 5: invalid keys "a"`}},
+		{source: "press", errs: []string{"line 1 column 1: usage: press ctrl+s"}},
+		{source: "click wheel", errs: []string{`line 1 column 7: unknown button "wheel"`}},
+		{source: "click left 0", errs: []string{`line 1 column 12: invalid count "0"`}},
+		{source: "move 100", errs: []string{"line 1 column 1: usage: move x,y [over duration]"}},
+		{source: "move 1,2 in 3s", errs: []string{"line 1 column 1: usage: move x,y [over duration]"}},
+		{source: "move a,2", errs: []string{`line 1 column 6: invalid coordinate "a"`}},
+		{source: "move 1,2 over soon", errs: []string{`line 1 column 15: invalid duration "soon", e.g. 500ms or 1.5s`}},
+		{source: "wait -1s", errs: []string{`line 1 column 6: invalid duration "-1s", e.g. 500ms or 1.5s`}},
+		{source: "repeat 2", errs: []string{"line 1 column 1: repeat needs a { ... } block"}},
+		{source: "key a { key b }", errs: []string{"line 1 column 1: key does not take a block"}},
+		{
+			source: "wait 1x\nrepeat x {\n  click nowhere\n}",
+			errs: []string{
+				`line 1 column 6: invalid duration "1x", e.g. 500ms or 1.5s`,
+				`line 2 column 8: invalid count "x"`,
+				`line 3 column 9: unknown button "nowhere"`,
+			},
+		},
+	} {
+		macro, err := ParseMacro(test.source)
+		if err != nil {
+			t.Errorf("%q: %v", test.source, err)
+			continue
+		}
+		err = macro.Validate()
+		var errs []string
+		var macroErrs MacroErrors
+		if errors.As(err, &macroErrs) {
+			for _, err := range macroErrs {
+				errs = append(errs, strings.TrimPrefix(err.Error(), "[error] macro "))
+			}
+		} else if err != nil {
+			t.Errorf("%q: failed with %T %v, want MacroErrors", test.source, err, err)
+		}
+		if !reflect.DeepEqual(errs, test.errs) {
+			t.Errorf("%q: validated with %q, want %q", test.source, errs, test.errs)
+		}
+	}
+}
+
+func TestMacroArguments(t *testing.T) {
+	macro, err := ParseMacro("key ctrl+s\nclick middle 2; move -3,4 over 1s\nrepeat 2 { wait 20ms }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := macro.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	key, click, move, repeat := macro.statements[0], macro.statements[1], macro.statements[2], macro.statements[3]
+	if !reflect.DeepEqual(key.keys, []EventCode{KEY_LEFTCTRL, KEY_S}) {
+		t.Errorf("key decoded as %v", key.keys)
+	}
+	if click.button != MiddleButton || click.count != 2 {
+		t.Errorf("click decoded as %v %d", click.button, click.count)
+	}
+	if move.target != (Point{X: -3, Y: 4}) || move.duration != time.Second {
+		t.Errorf("move decoded as %+v over %v", move.target, move.duration)
+	}
+	if repeat.count != 2 || len(repeat.body) != 1 || repeat.body[0].duration != 20*time.Millisecond {
+		t.Errorf("repeat decoded as %d times %+v", repeat.count, repeat.body)
+	}
+}
+
+func TestMacroRun(t *testing.T) {
+	var trace bytes.Buffer
+	keyboard, err := Keyboard.DryRun("macro keyboard", Trace{Writer: &trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mouse, err := Mouse.DryRun("macro mouse", Trace{Writer: &trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyboard.Clock, mouse.Clock = NewFakeClock(clockStart), NewFakeClock(clockStart)
+	macro, err := ParseMacro("press shift\nrepeat 2 { key a }\nclick right\nmove 10,0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := macro.Run(context.Background(), keyboard, mouse); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"KEY_LEFTSHIFT pressed", "KEY_A pressed", "BTN_RIGHT released", "REL_X 10", "KEY_LEFTSHIFT released"} {
+		if !strings.Contains(trace.String(), want) {
+			t.Errorf("traced %q, want %q", trace.String(), want)
+		}
+	}
+	if strings.Count(trace.String(), "KEY_A pressed") != 2 {
+		t.Errorf("repeated key a %d times, want 2", strings.Count(trace.String(), "KEY_A pressed"))
+	}
+	if keyboard.IsPressed(KEY_LEFTSHIFT) {
+		t.Error("shift still held after the macro ended")
+	}
+
+	macro, _ = ParseMacro("click left")
+	if err := macro.Run(context.Background(), keyboard, nil); err == nil || !strings.Contains(err.Error(), "no pointer to click") {
+		t.Errorf("clicked without a pointer: %v", err)
+	}
+}
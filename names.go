@@ -0,0 +1,142 @@
+package uinput
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+//go:generate go run gen/gen_event_names.go -codes event_codes.go -output event_names.go
+
+type eventCodeName struct {
+	eventType EventType
+	code      EventCode
+	name      string
+}
+
+var eventTypeNames = map[EventType]string{
+	EV_SYN:       "EV_SYN",
+	EV_KEY:       "EV_KEY",
+	EV_REL:       "EV_REL",
+	EV_ABS:       "EV_ABS",
+	EV_MSC:       "EV_MSC",
+	EV_SW:        "EV_SW",
+	EV_LED:       "EV_LED",
+	EV_SND:       "EV_SND",
+	EV_REP:       "EV_REP",
+	EV_FF:        "EV_FF",
+	EV_PWR:       "EV_PWR",
+	EV_FF_STATUS: "EV_FF_STATUS",
+}
+
+var syncCodeNames = []eventCodeName{
+	{EV_SYN, EventCode(SYN_REPORT), "SYN_REPORT"},
+	{EV_SYN, EventCode(SYN_CONFIG), "SYN_CONFIG"},
+	{EV_SYN, EventCode(SYN_MT_REPORT), "SYN_MT_REPORT"},
+	{EV_SYN, EventCode(SYN_DROPPED), "SYN_DROPPED"},
+}
+
+var propertyNames = map[DeviceProperty]string{
+	INPUT_PROP_POINTER:        "INPUT_PROP_POINTER",
+	INPUT_PROP_DIRECT:         "INPUT_PROP_DIRECT",
+	INPUT_PROP_BUTTONPAD:      "INPUT_PROP_BUTTONPAD",
+	INPUT_PROP_SEMI_MT:        "INPUT_PROP_SEMI_MT",
+	INPUT_PROP_TOPBUTTONPAD:   "INPUT_PROP_TOPBUTTONPAD",
+	INPUT_PROP_POINTING_STICK: "INPUT_PROP_POINTING_STICK",
+	INPUT_PROP_ACCELEROMETER:  "INPUT_PROP_ACCELEROMETER",
+}
+
+var (
+	namesOnce  sync.Once
+	codeNames  map[EventType]map[EventCode]string
+	namedCodes map[string]eventCodeName
+	namedTypes map[string]EventType
+	namedProps map[string]DeviceProperty
+)
+
+func loadNames() {
+	codeNames = make(map[EventType]map[EventCode]string)
+	namedCodes = make(map[string]eventCodeName)
+	for _, entry := range append(syncCodeNames, eventCodeNames...) {
+		if codeNames[entry.eventType] == nil {
+			codeNames[entry.eventType] = make(map[EventCode]string)
+		}
+		codeNames[entry.eventType][entry.code] = entry.name
+		namedCodes[entry.name] = entry
+	}
+	namedTypes = make(map[string]EventType)
+	for eventType, name := range eventTypeNames {
+		namedTypes[name] = eventType
+	}
+	namedProps = make(map[string]DeviceProperty)
+	for property, name := range propertyNames {
+		namedProps[name] = property
+	}
+}
+
+// String returns the input.h name of the event type, e.g. "EV_KEY".
+func (self EventType) String() string {
+	if name, ok := eventTypeNames[self]; ok {
+		return name
+	}
+	return fmt.Sprintf("EV_%d", int(self))
+}
+
+func (self DeviceProperty) String() string {
+	if name, ok := propertyNames[self]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", uint16(self))
+}
+
+// CodeName returns the input-event-codes.h name of the code for the event
+// type, e.g. "KEY_A" or "REL_X", or the code in hex when it has no name.
+func CodeName(eventType EventType, code EventCode) string {
+	namesOnce.Do(loadNames)
+	if name, ok := codeNames[eventType][code]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", uint16(code))
+}
+
+// ParseEventType accepts a name such as "EV_KEY" or a kernel type number.
+func ParseEventType(name string) (EventType, error) {
+	namesOnce.Do(loadNames)
+	if eventType, ok := namedTypes[name]; ok {
+		return eventType, nil
+	}
+	if number, err := strconv.ParseUint(name, 0, 16); err == nil {
+		if eventType := MarshalEventType(int(number)); eventType.Code() == uint16(number) {
+			return eventType, nil
+		}
+	}
+	return 0, fmt.Errorf("[error] unknown event type %q", name)
+}
+
+// ParseEventCode accepts a name such as "KEY_A", which must belong to the
+// event type, or a code number.
+func ParseEventCode(eventType EventType, name string) (EventCode, error) {
+	namesOnce.Do(loadNames)
+	if entry, ok := namedCodes[name]; ok {
+		if entry.eventType != eventType {
+			return 0, fmt.Errorf("[error] %v is not an %v code", name, eventType)
+		}
+		return entry.code, nil
+	}
+	if number, err := strconv.ParseUint(name, 0, 16); err == nil {
+		return EventCode(number), nil
+	}
+	return 0, fmt.Errorf("[error] unknown %v code %q", eventType, name)
+}
+
+// ParseProperty accepts a name such as "INPUT_PROP_POINTER" or a number.
+func ParseProperty(name string) (DeviceProperty, error) {
+	namesOnce.Do(loadNames)
+	if property, ok := namedProps[name]; ok {
+		return property, nil
+	}
+	if number, err := strconv.ParseUint(name, 0, 16); err == nil {
+		return DeviceProperty(number), nil
+	}
+	return 0, fmt.Errorf("[error] unknown device property %q", name)
+}
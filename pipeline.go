@@ -0,0 +1,350 @@
+package uinput
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Transform rewrites the events flowing through a Pipeline. It is handed every
+// event read from the grabbed devices, synchronization events included, and
+// emits any number of events in its place: none to drop it, the event itself
+// to pass it through.
+type Transform interface {
+	Transform(event InputEvent, emit func(InputEvent))
+}
+
+type TransformFunc func(event InputEvent, emit func(InputEvent))
+
+func (self TransformFunc) Transform(event InputEvent, emit func(InputEvent)) {
+	self(event, emit)
+}
+
+// RemapKeys replaces the codes of key and button events, e.g.
+// {KEY_CAPSLOCK: KEY_ESC}.
+func RemapKeys(remap map[EventCode]EventCode) Transform {
+	return TransformFunc(func(event InputEvent, emit func(InputEvent)) {
+		if event.Type == EV_KEY.Code() {
+			if code, ok := remap[EventCode(event.Code)]; ok {
+				event.Code = uint16(code)
+			}
+		}
+		emit(event)
+	})
+}
+
+// SwapButtons exchanges two keys or buttons, e.g. BTN_LEFT and BTN_RIGHT.
+func SwapButtons(a, b EventCode) Transform {
+	return RemapKeys(map[EventCode]EventCode{a: b, b: a})
+}
+
+// DropEvents drops every event matching drop.
+func DropEvents(drop func(InputEvent) bool) Transform {
+	return TransformFunc(func(event InputEvent, emit func(InputEvent)) {
+		if !drop(event) {
+			emit(event)
+		}
+	})
+}
+
+// TimedTransform is a Transform that also acts on the passing of time, such
+// as deciding a tap-hold key was held. Tick is called once Deadline passed on
+// the clock Now reads, which is the transform's own.
+type TimedTransform interface {
+	Transform
+	Deadline() (time.Time, bool)
+	Now() time.Time
+	Tick(emit func(InputEvent))
+}
+
+// chainTransforms feeds the output of each transform into the next one, the
+// last emitting into emit. It returns the input of every transform, followed
+// by emit.
+func chainTransforms(transforms []Transform, emit func(InputEvent)) []func(InputEvent) {
+	inputs := make([]func(InputEvent), len(transforms)+1)
+	inputs[len(transforms)] = emit
+	for n := len(transforms) - 1; n >= 0; n-- {
+		transform, next := transforms[n], inputs[n+1]
+		inputs[n] = func(event InputEvent) { transform.Transform(event, next) }
+	}
+	return inputs
+}
+
+// tickTransforms calls Tick on the timed transforms whose deadline passed by
+// their own clock, and returns how long until the earliest deadline left.
+func tickTransforms(transforms []Transform, inputs []func(InputEvent)) (wait time.Duration, ok bool) {
+	for n, transform := range transforms {
+		timed, isTimed := transform.(TimedTransform)
+		if !isTimed {
+			continue
+		}
+		deadline, waiting := timed.Deadline()
+		if waiting && !timed.Now().Before(deadline) {
+			timed.Tick(inputs[n+1])
+			deadline, waiting = timed.Deadline()
+		}
+		if !waiting {
+			continue
+		}
+		if left := deadline.Sub(timed.Now()); !ok || left < wait {
+			wait, ok = left, true
+		}
+	}
+	return wait, ok
+}
+
+// Pipeline grabs physical input devices for exclusive use and re-emits their
+// events, rewritten by its transforms, on a virtual device combining their
+// capabilities; the way keyd or kmonad remap a keyboard.
+type Pipeline struct {
+	// Sources are the input event devices grabbed, e.g. "/dev/input/event3".
+	Sources    []string
+	Transforms []Transform
+	// Name of the virtual device, the name of the first source by default.
+	Name string
+	// Capabilities the transforms emit that the sources lack, e.g. the
+	// target of a remapped key. Transforms with a Capabilities method, like
+	// KeyEngine, add theirs.
+	Capabilities map[EventType][]EventCode
+}
+
+// Run grabs the sources and passes their events through until ctx is done or
+// a source fails. The sources are released when it returns.
+func (self *Pipeline) Run(ctx context.Context) error {
+	if len(self.Sources) == 0 {
+		return fmt.Errorf("[error] pipeline has no source devices")
+	}
+	var readers []*EventReader
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+	var descriptions []DeviceDescription
+	for _, path := range self.Sources {
+		reader, err := OpenEventReader(path)
+		if err != nil {
+			return err
+		}
+		readers = append(readers, reader)
+		description, err := reader.Description()
+		if err != nil {
+			return fmt.Errorf("[error] failed to describe %v: %v", path, err)
+		}
+		descriptions = append(descriptions, description)
+	}
+
+	device, err := self.description(descriptions).Connect()
+	if err != nil {
+		return err
+	}
+	dev := device.(*Device)
+	defer dev.Disconnect()
+	time.Sleep(replaySettleTime)
+	writer := &frameWriter{device: dev}
+
+	// NOTE: Grabbing only once the virtual device exists leaves the sources
+	// usable should creating it fail.
+	for n, reader := range readers {
+		if err := reader.Grab(); err != nil {
+			return fmt.Errorf("[error] failed to grab %v: %v", self.Sources[n], err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	frames := make(chan []InputEvent)
+	errs := make(chan error, len(readers))
+	var wait sync.WaitGroup
+	defer func() {
+		cancel()
+		wait.Wait()
+	}()
+	for n, reader := range readers {
+		wait.Add(1)
+		go func(path string, reader *EventReader) {
+			defer wait.Done()
+			if err := readFrames(ctx, reader, frames); err != nil {
+				errs <- fmt.Errorf("[error] failed to read event from %v: %v", path, err)
+			}
+		}(self.Sources[n], reader)
+	}
+	// NOTE: Closing the readers is the only way to interrupt blocked reads.
+	go func() {
+		<-ctx.Done()
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+
+	var writeErr error
+	inputs := chainTransforms(self.Transforms, func(event InputEvent) {
+		if writeErr == nil {
+			writeErr = writer.Event(event)
+		}
+	})
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case frame := <-frames:
+			for _, event := range frame {
+				inputs[0](event)
+			}
+		case <-timer.C:
+		}
+		if writeErr != nil {
+			return fmt.Errorf("[error] failed to write event: %v", writeErr)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if wait, ok := tickTransforms(self.Transforms, inputs); ok {
+			timer.Reset(wait)
+		}
+	}
+}
+
+// readFrames sends the events read up to and including each SYN_REPORT as one
+// frame, so frames of different sources are never interleaved.
+//
+// After a SYN_DROPPED, events are discarded up to the next SYN_REPORT as evdev
+// requires, and the keys whose state changed meanwhile are sent as a frame of
+// their own, read back from the device.
+func readFrames(ctx context.Context, reader *EventReader, frames chan<- []InputEvent) error {
+	var frame []InputEvent
+	pressed := make(map[EventCode]bool)
+	dropped := false
+	for {
+		event, err := reader.ReadEvent()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if event.Type == EV_SYN.Code() && event.Code == uint16(DroppedSync) {
+			frame, dropped = nil, true
+			continue
+		}
+		if event.Type != EV_SYN.Code() || event.Code != uint16(ReportSync) {
+			if !dropped {
+				frame = append(frame, event)
+			}
+			continue
+		}
+		if dropped {
+			dropped = false
+			if frame, err = resyncKeys(reader, pressed); err != nil {
+				return err
+			}
+			if len(frame) == 0 {
+				continue
+			}
+		}
+		frame = append(frame, event)
+		for _, event := range frame {
+			if event.Type == EV_KEY.Code() && event.Value != keyRepeat {
+				pressed[EventCode(event.Code)] = event.Value != 0
+			}
+		}
+		select {
+		case frames <- frame:
+			frame = nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// resyncKeys returns the events pressing and releasing the keys whose state on
+// the device differs from pressed.
+func resyncKeys(reader *EventReader, pressed map[EventCode]bool) (events []InputEvent, err error) {
+	keys, err := reader.PressedKeys()
+	if err != nil {
+		return nil, err
+	}
+	down := make(map[EventCode]bool)
+	for _, key := range keys {
+		down[key] = true
+		if !pressed[key] {
+			events = append(events, buttonInputEvent(key, KeyPressed.Code()))
+		}
+	}
+	for key, isPressed := range pressed {
+		if isPressed && !down[key] {
+			events = append(events, buttonInputEvent(key, KeyReleased.Code()))
+		}
+	}
+	return events, nil
+}
+
+// description combines the descriptions of the sources into the one of the
+// virtual device, taking the identity and axis ranges of the first source
+// reporting them.
+func (self *Pipeline) description(sources []DeviceDescription) DeviceDescription {
+	description := DeviceDescription{
+		Name:         sources[0].Name,
+		Id:           sources[0].Id,
+		Capabilities: make(map[EventType][]EventCode),
+		Abs:          make(map[EventCode]AbsInfo),
+	}
+	if self.Name != "" {
+		description.Name = self.Name
+	}
+	codes := make(map[EventType]map[EventCode]bool)
+	addCodes := func(capabilities map[EventType][]EventCode) {
+		for eventType, eventCodes := range capabilities {
+			if codes[eventType] == nil {
+				codes[eventType] = make(map[EventCode]bool)
+			}
+			for _, code := range eventCodes {
+				codes[eventType][code] = true
+			}
+		}
+	}
+	properties := make(map[DeviceProperty]bool)
+	for _, source := range sources {
+		addCodes(source.Capabilities)
+		for axis, info := range source.Abs {
+			if _, ok := description.Abs[axis]; !ok {
+				description.Abs[axis] = info
+			}
+		}
+		for _, property := range source.Properties {
+			if !properties[property] {
+				properties[property] = true
+				description.Properties = append(description.Properties, property)
+			}
+		}
+	}
+	addCodes(self.Capabilities)
+	for _, transform := range self.Transforms {
+		if capabilities, ok := transform.(interface {
+			Capabilities() map[EventType][]EventCode
+		}); ok {
+			addCodes(capabilities.Capabilities())
+		}
+	}
+	for eventType, eventCodes := range codes {
+		// NOTE: Force feedback is not passed through, effects uploaded to
+		// the virtual device would have to be forwarded to the sources. Nor
+		// is autorepeat, the repeats of the sources pass through already and
+		// kernel repeat on the virtual device would double them.
+		if eventType == EV_FF || eventType == EV_REP {
+			continue
+		}
+		description.Capabilities[eventType] = []EventCode{}
+		for code := range eventCodes {
+			description.Capabilities[eventType] = append(description.Capabilities[eventType], code)
+		}
+	}
+	return description
+}
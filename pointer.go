@@ -2,7 +2,6 @@ package uinput
 
 import (
 	"fmt"
-	"syscall"
 )
 
 type MoveDirection int
@@ -51,7 +50,7 @@ type MoveEvent struct {
 	Axis        AxisType
 	Code        int32
 	Value       int32
-	NewPosition position
+	NewPosition Point
 }
 
 func (self MoveEvent) InputEvent() (event InputEvent) {
@@ -73,19 +72,27 @@ func (self MoveEvent) InputEvent() (event InputEvent) {
 	return event
 }
 
-type position struct {
+// Point is a position on the absolute axes, or a movement on the relative
+// ones.
+type Point struct {
 	X int32
 	Y int32
 }
 
-func (self position) Slice() (absolute [size]int32) {
+// NewPosition returns a Point usable with AbsoluteMoveTo, RelativeMove and
+// Drag.
+func NewPosition(x, y int32) Point {
+	return Point{X: x, Y: y}
+}
+
+func (self Point) Slice() (absolute [size]int32) {
 	absolute[XAxis.Code()] = self.X
 	absolute[YAxis.Code()] = self.Y
 	return absolute
 }
 
 // TODO: Make a struct to hold this data and a func that outputs it in this way
-func (self position) AbsoluteMoveEvents() (events [2]InputEvent) {
+func (self Point) AbsoluteMoveEvents() (events [2]InputEvent) {
 	events[0].Type = absoluteEvent.UInt16()
 	events[0].Code = XAxis.Code()
 	events[0].Value = self.X
@@ -96,7 +103,7 @@ func (self position) AbsoluteMoveEvents() (events [2]InputEvent) {
 	return events
 }
 
-func (self position) RelativeMoveEvents() (events [2]InputEvent) {
+func (self Point) RelativeMoveEvents() (events [2]InputEvent) {
 	events[0].Type = relativeEvent.UInt16()
 	events[0].Code = XAxis.Code()
 	events[0].Value = self.X
@@ -124,42 +131,44 @@ func (self position) RelativeMoveEvents() (events [2]InputEvent) {
 
 // TODO: we should be merging coordinates (x,y) into a single object
 
-func (self Device) AbsoluteMoveTo(newPosition position) error {
-	uinputEvents := newPosition.AbsoluteMoveEvents()
-	for _, event := range uinputEvents {
-		if eventBuffer, err := appendEvent(event); err != nil {
-			return fmt.Errorf("[error] writing abs event failed: %v", err)
-			if _, err = self.FD.Write(eventBuffer); err != nil {
-				return fmt.Errorf("[error] failed to write abs event to device file: %v", err)
-			}
-		}
+func (self *Device) AbsoluteMoveTo(newPosition Point) error {
+	events := newPosition.AbsoluteMoveEvents()
+	if err := self.writeFrame(events[:]...); err != nil {
+		return fmt.Errorf("[error] failed to write abs event to device file: %w", err)
+	}
+	return nil
+}
+
+// RelativeMove moves the pointer by the x and y distance in delta, emitting
+// both axes in a single frame so diagonal movement is not split in two.
+func (self *Device) RelativeMove(delta Point) error {
+	events := delta.RelativeMoveEvents()
+	if err := self.writeFrame(events[:]...); err != nil {
+		return fmt.Errorf("[error] failed to write rel event to device file: %w", err)
 	}
-	return self.SyncEvents()
+	return nil
 }
 
 // TODO: Why do we need event code? Shouldnt it be fixed? And pixel seems wierd
 // name for distance to move relative
 
-func (self Device) RelativeMoveTo(eventCode uint16, pixels int32) error {
+func (self *Device) RelativeMoveTo(eventCode uint16, pixels int32) error {
 	inputEvent := InputEvent{
-		Time:  syscall.Timeval{Sec: 0, Usec: 0},
+		Time:  Timeval{Sec: 0, Usec: 0},
 		Type:  relativeEvent.Code(),
 		Code:  eventCode,
 		Value: pixels,
 	}
-	if eventBuffer, err := appendEvent(inputEvent); err != nil {
-		return fmt.Errorf("[error] writing abs event failed: %v", err)
-		if _, err = self.FD.Write(eventBuffer); err != nil {
-			return fmt.Errorf("[error] failed to write rel event to device file: %v", err)
-		}
+	if err := self.writeFrame(inputEvent); err != nil {
+		return fmt.Errorf("[error] failed to write rel event to device file: %w", err)
 	}
-	return self.SyncEvents()
+	return nil
 }
 
 // TODO: Break these out into RelativeMoveLeft(pixels) to greatly simplify
 // interaction with a given device. Then probably horizontal and vertical
 // be broken off too.
-func (self Device) Move(direction MoveDirection, pixels int32) error {
+func (self *Device) Move(direction MoveDirection, pixels int32) error {
 	switch direction {
 	case Up:
 		return self.RelativeMoveTo(YAxis.Code(), -pixels)
@@ -174,7 +183,7 @@ func (self Device) Move(direction MoveDirection, pixels int32) error {
 	}
 }
 
-func (self Device) Click(buttonType ButtonType) error {
+func (self *Device) Click(buttonType ButtonType) error {
 	if err := self.PressButton(buttonType); err != nil {
 		return err
 	}
@@ -184,16 +193,16 @@ func (self Device) Click(buttonType ButtonType) error {
 	return nil
 }
 
-func (self Device) PressButton(buttonType ButtonType) error {
-	if err := sendButtonEvent(self.FD, buttonType.EventCode(), KeyPressed.Code()); err != nil {
-		return fmt.Errorf("[error] failed press the left mouse button: %v", err)
+func (self *Device) PressButton(buttonType ButtonType) error {
+	if err := self.writeFrame(buttonInputEvent(EventCode(buttonType.EventCode()), KeyPressed.Code())); err != nil {
+		return fmt.Errorf("[error] failed press the left mouse button: %w", err)
 	}
-	return self.SyncEvents()
+	return nil
 }
 
-func (self Device) ReleaseButton(buttonType ButtonType) error {
-	if err := sendButtonEvent(self.FD, buttonType.EventCode(), KeyReleased.Code()); err != nil {
-		return fmt.Errorf("[error] failed press the left mouse button: %v", err)
+func (self *Device) ReleaseButton(buttonType ButtonType) error {
+	if err := self.writeFrame(buttonInputEvent(EventCode(buttonType.EventCode()), KeyReleased.Code())); err != nil {
+		return fmt.Errorf("[error] failed press the left mouse button: %w", err)
 	}
-	return self.SyncEvents()
+	return nil
 }
@@ -50,7 +50,7 @@ func newDeviceInfo() *deviceInfo {
 }
 
 func (self *deviceInfo) String() string {
-	return fmt.Sprintf("device info: name[", self.name, "] located at path[", self.path, "]")
+	return fmt.Sprintf("device info: name[%v] located at path[%v]", self.name, self.path)
 }
 
 // isKeyboard returns true if this appears to be a keyboard device.
@@ -192,3 +192,25 @@ func getDevicePath(sysdir, root string) (string, error) {
 	}
 	return "", fmt.Errorf("[error] no event directories found in %v", sysdir)
 }
+
+// InputDevice is an input event device known to the kernel.
+type InputDevice struct {
+	// Path of the event device, e.g. "/dev/input/event3", empty for devices
+	// without one.
+	Path        string            `json:"path"`
+	Description DeviceDescription `json:"description"`
+}
+
+// ListDevices lists the input devices in /proc/bus/input/devices. Axis
+// ranges are left out, DescribeDevice reads them.
+func ListDevices() ([]InputDevice, error) {
+	infos, err := readDevices("")
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]InputDevice, 0, len(infos))
+	for _, info := range infos {
+		devices = append(devices, InputDevice{Path: info.path, Description: info.description()})
+	}
+	return devices, nil
+}
@@ -2,13 +2,8 @@ package uinput
 
 import (
 	"context"
-	"encoding/binary"
-	"fmt"
 	"io"
 	"os"
-	"syscall"
-	"time"
-	"unsafe"
 )
 
 //go:generate go run gen/gen_constants.go ../../../../../../../third_party/kernel/v4.14/include/uapi/linux/input-event-codes.h generated_constants.go
@@ -16,8 +11,8 @@ import (
 
 // RawEventWriter supports injecting raw input events into a device.
 type RawEventWriter struct {
-	w       io.WriteCloser // device
-	nowFunc func() time.Time
+	w     io.WriteCloser // device
+	clock Clock
 }
 
 // Device returns a RawEventWriter for injecting input events into the input event device at path.
@@ -26,7 +21,12 @@ func RawDevice(ctx context.Context, path string) (*RawEventWriter, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &RawEventWriter{f, time.Now}, nil
+	return &RawEventWriter{f, SystemClock}, nil
+}
+
+// SetClock sets the clock stamping the events, SystemClock by default.
+func (ew *RawEventWriter) SetClock(clock Clock) {
+	ew.clock = clock
 }
 
 // Close closes the device.
@@ -36,37 +36,10 @@ func (ew *RawEventWriter) Close() error {
 
 // Event injects an event containing the supplied values into the device.
 func (ew *RawEventWriter) Event(et EventType, ec EventCode, val int32) error {
-	tv := syscall.NsecToTimeval(ew.nowFunc().UnixNano())
-
-	// input_event contains a timeval struct, which uses "long" for its members.
-	// binary.Write wants explicitly-sized data, so we need to pass a different
-	// struct depending on the system's int size.
-	var ev interface{}
-	switch intSize := unsafe.Sizeof(int(1)); intSize {
-	case 4:
-		ev = &event32{int32(tv.Sec), int32(tv.Usec), uint16(et), uint16(ec), val}
-	case 8:
-		ev = &event64{tv, uint16(et), uint16(ec), val}
-	default:
-		return fmt.Errorf("[errors] unexpected int size of %d byte(s)", intSize)
-	}
-
-	// Little-endian is appropriate regardless of the system's underlying endianness.
-	return binary.Write(ew.w, binary.LittleEndian, ev)
-}
-
-// event32 corresponds to a 32-bit input_event struct.
-type event32 struct {
-	Sec, Usec  int32
-	Type, Code uint16
-	Val        int32
-}
-
-// event64 corresponds to a 64-bit input_event struct.
-type event64 struct {
-	Tv         syscall.Timeval
-	Type, Code uint16
-	Val        int32
+	event := InputEvent{Type: et.Code(), Code: uint16(ec), Value: val}
+	event.SetTimestamp(ew.clock.Now())
+	_, err := ew.w.Write(NativeEventLayout.Append(nil, event))
+	return err
 }
 
 // Sync writes a synchronization event delineating a packet of input data occurring at a single point in time.
@@ -0,0 +1,109 @@
+package uinput
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// NOTE: A desktop never sees the release of a key held when its device is
+// destroyed and keeps it stuck down, so whatever the device state holds is
+// released first.
+
+// releaseEvents lifts every touch, then releases the keys in the reverse
+// order they were pressed so modifiers go last, as when letting go by hand.
+func (self *Device) releaseEvents() []InputEvent {
+	var events []InputEvent
+	for slot := range self.state.Touches {
+		events = append(events,
+			InputEvent{Type: EV_ABS.Code(), Code: uint16(ABS_MT_SLOT), Value: slot},
+			InputEvent{Type: EV_ABS.Code(), Code: uint16(ABS_MT_TRACKING_ID), Value: -1},
+		)
+	}
+	for n := len(self.state.Pressed) - 1; n >= 0; n-- {
+		key := self.state.Pressed[n]
+		events = append(events, self.scanCodeEvents(key)...)
+		events = append(events, buttonInputEvent(key, KeyReleased.Code()))
+	}
+	return events
+}
+
+// ReleaseAll releases every key and button held and lifts every touch in
+// contact, in a single frame. Disconnect does so before removing the device.
+func (self *Device) ReleaseAll() error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.releaseAllLocked()
+}
+
+func (self *Device) releaseAllLocked() error {
+	events := self.releaseEvents()
+	if len(events) == 0 {
+		return nil
+	}
+	return self.writeEventsLocked(events)
+}
+
+// ReleaseWhenDone releases everything held on the device once ctx is done,
+// for work cancelled midway through a key or button press. Calling stop
+// before ctx is done cancels it.
+func (self *Device) ReleaseWhenDone(ctx context.Context) (stop func()) {
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			self.ReleaseAll()
+		case <-stopped:
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(stopped) }) }
+}
+
+// ReleaseOnSignal disconnects the device, releasing everything held first,
+// when the process receives one of signals, SIGINT and SIGTERM by default.
+// The handlers the application registered with signal.Notify receive the
+// signal as well and decide whether to exit; a program without handlers of
+// its own should use ReleaseOnSignalAndRaise, or the signal no longer stops
+// it. Calling stop removes the handler.
+//
+// NOTE: A panic is not a signal; defer Disconnect in the goroutines using the
+// device to release its keys when they panic.
+func (self *Device) ReleaseOnSignal(signals ...os.Signal) (stop func()) {
+	return self.releaseOnSignal(false, signals)
+}
+
+// ReleaseOnSignalAndRaise is ReleaseOnSignal raising the signal again once the
+// device is disconnected, so it still ends the process. Handlers registered
+// with signal.Notify would receive the signal twice.
+func (self *Device) ReleaseOnSignalAndRaise(signals ...os.Signal) (stop func()) {
+	return self.releaseOnSignal(true, signals)
+}
+
+func (self *Device) releaseOnSignal(raise bool, signals []os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	received := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	signal.Notify(received, signals...)
+	go func() {
+		select {
+		case sig := <-received:
+			signal.Stop(received)
+			self.Disconnect()
+			if !raise {
+				return
+			}
+			if process, err := os.FindProcess(os.Getpid()); err == nil {
+				process.Signal(sig)
+			}
+		case <-stopped:
+			signal.Stop(received)
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(stopped) }) }
+}
@@ -0,0 +1,44 @@
+package uinput
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReleaseOnSignalDeliversOnce(t *testing.T) {
+	device, err := Keyboard.DryRun("signalled keyboard", Trace{Writer: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := device.PressKey(KEY_LEFTSHIFT); err != nil {
+		t.Fatal(err)
+	}
+	handler := make(chan os.Signal, 2)
+	signal.Notify(handler, syscall.SIGUSR1)
+	defer signal.Stop(handler)
+	defer device.ReleaseOnSignal(syscall.SIGUSR1)()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-handler:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the application handler missed the signal")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for device.IsPressed(KEY_LEFTSHIFT) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := device.SyncEvents(); err != ErrDisconnected {
+		t.Errorf("device still connected after the signal: %v", err)
+	}
+	select {
+	case <-handler:
+		t.Error("the application handler received the signal twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
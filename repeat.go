@@ -0,0 +1,93 @@
+package uinput
+
+import (
+	"fmt"
+	"time"
+)
+
+// keyRepeat is the EV_KEY value reporting that a held key repeated, alongside
+// the press (1) and release (0) values of Key.
+const keyRepeat = 2
+
+// Autorepeat configures key repeat on a keyboard. With Kernel set, EV_REP is
+// registered and the input core repeats held keys itself after Delay, every
+// Period; otherwise HoldKey emits the repeat events at that rate. Zero
+// durations fall back to DefaultAutorepeat, which matches the kernel defaults.
+type Autorepeat struct {
+	Kernel bool
+	Delay  time.Duration
+	Period time.Duration
+}
+
+var DefaultAutorepeat = Autorepeat{
+	Delay:  250 * time.Millisecond,
+	Period: 33 * time.Millisecond,
+}
+
+func (self Autorepeat) withDefaults() Autorepeat {
+	if self.Delay == 0 {
+		self.Delay = DefaultAutorepeat.Delay
+	}
+	if self.Period == 0 {
+		self.Period = DefaultAutorepeat.Period
+	}
+	return self
+}
+
+func (self *Device) RegisterAutorepeat() error {
+	if err := self.newEventSource(EV_REP); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register autorepeat events: %v", err)
+	}
+	return nil
+}
+
+// reportAutorepeat sets the kernel repeat rate; writing EV_REP events into a
+// created uinput device updates its REP_DELAY and REP_PERIOD values.
+func (self *Device) reportAutorepeat() error {
+	repeat := self.Autorepeat.withDefaults()
+	var events []InputEvent
+	for _, rate := range []struct {
+		code  EventCode
+		value time.Duration
+	}{
+		{REP_DELAY, repeat.Delay},
+		{REP_PERIOD, repeat.Period},
+	} {
+		events = append(events, InputEvent{
+			Type:  repeatEvent.UInt16(),
+			Code:  uint16(rate.code),
+			Value: int32(rate.value.Milliseconds()),
+		})
+	}
+	if err := self.writeFrame(events...); err != nil {
+		return fmt.Errorf("[error] failed to set autorepeat rate: %v", err)
+	}
+	return nil
+}
+
+// HoldKey holds the key down for duration. When kernel autorepeat is enabled
+// the input core generates the repeats, otherwise they are emitted here at
+// the configured Autorepeat rate, as a physical keyboard would.
+func (self *Device) HoldKey(key EventCode, duration time.Duration) error {
+	if err := self.PressKey(key); err != nil {
+		return err
+	}
+	repeat := self.Autorepeat.withDefaults()
+	clock := self.clock()
+	if self.Autorepeat.Kernel || duration <= repeat.Delay {
+		clock.Sleep(duration)
+		return self.ReleaseKey(key)
+	}
+	start := clock.Now()
+	clock.Sleep(repeat.Delay)
+	for clock.Now().Sub(start)+repeat.Period <= duration {
+		if err := self.writeFrame(buttonInputEvent(key, keyRepeat)); err != nil {
+			self.ReleaseKey(key)
+			return fmt.Errorf("[error] failed to issue the key repeat event: %v", err)
+		}
+		clock.Sleep(repeat.Period)
+	}
+	clock.Sleep(duration - clock.Now().Sub(start))
+	return self.ReleaseKey(key)
+}
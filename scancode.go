@@ -0,0 +1,52 @@
+package uinput
+
+import (
+	"fmt"
+)
+
+//go:generate go run gen/gen_hid_usages.go /usr/src/linux/drivers/hid/hid-input.c event_codes.go hid_usages.go
+
+// hidKeyboardPage is the HID usage page of keyboard keys; MSC_SCAN carries the
+// page in the upper 16 bits and the usage id in the lower ones.
+const hidKeyboardPage = 0x07
+
+// HIDUsage returns the full HID usage (page and id) a physical USB keyboard
+// reports in MSC_SCAN for the key, e.g. 0x70004 for KEY_A.
+func HIDUsage(key EventCode) (uint32, bool) {
+	usage, ok := hidKeyboardUsages[key]
+	if !ok {
+		return 0, false
+	}
+	return hidKeyboardPage<<16 | uint32(usage), true
+}
+
+func (self *Device) RegisterScanCodes() error {
+	if err := self.newEventSource(EV_MSC); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register misc events: %v", err)
+	}
+	if err := ioctl(self.FD, MiscBit.Code(), uintptr(MSC_SCAN)); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register scan code events: %v", err)
+	}
+	return nil
+}
+
+// scanCodeEvents returns the MSC_SCAN event reporting the HID usage of the
+// key ahead of its EV_KEY event the way hid-input does, when the device was
+// created with ScanCodes enabled. Keys without a keyboard page usage are sent
+// without one, as on hardware.
+func (self *Device) scanCodeEvents(key EventCode) []InputEvent {
+	if !self.ScanCodes {
+		return nil
+	}
+	usage, ok := HIDUsage(key)
+	if !ok {
+		return nil
+	}
+	return []InputEvent{{
+		Type:  miscEvent.UInt16(),
+		Code:  uint16(MSC_SCAN),
+		Value: int32(usage),
+	}}
+}
@@ -0,0 +1,27 @@
+package uinput
+
+import "fmt"
+
+// RegisterWheels registers the vertical and horizontal scroll wheels, on top
+// of the relative axes registered by RegisterAxis.
+func (self *Device) RegisterWheels() error {
+	for _, wheel := range []EventCode{REL_WHEEL, REL_HWHEEL} {
+		if err := ioctl(self.FD, RelativeMovement.Code(), uintptr(wheel)); err != nil {
+			self.FD.Close()
+			return fmt.Errorf("[error] failed to register scroll wheel %d: %v", wheel, err)
+		}
+	}
+	return nil
+}
+
+// Scroll turns the scroll wheel by a number of detents, up for positive clicks
+// and down for negative ones.
+func (self *Device) Scroll(clicks int32) error {
+	return self.RelativeMoveTo(uint16(REL_WHEEL), clicks)
+}
+
+// HorizontalScroll turns the horizontal scroll wheel by a number of detents,
+// right for positive clicks and left for negative ones.
+func (self *Device) HorizontalScroll(clicks int32) error {
+	return self.RelativeMoveTo(uint16(REL_HWHEEL), clicks)
+}
@@ -0,0 +1,156 @@
+package uinput
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SessionWriter stores a recorded input session: the description of the
+// recorded device followed by its events in the order they were reported.
+type SessionWriter interface {
+	WriteDescription(DeviceDescription) error
+	WriteEvent(InputEvent) error
+}
+
+// SessionReader reads back a session stored by a SessionWriter. ReadEvent
+// returns io.EOF once every event was read.
+type SessionReader interface {
+	ReadDescription() (DeviceDescription, error)
+	ReadEvent() (InputEvent, error)
+}
+
+// sessionStart rebases event times on the first event written to a session,
+// so recordings start at zero whatever clock stamped them.
+type sessionStart struct {
+	started bool
+	nanos   int64
+}
+
+func (self *sessionStart) offset(event InputEvent) Timeval {
+	if !self.started {
+		self.started, self.nanos = true, event.Time.Nano()
+	}
+	return NsecToTimeval(event.Time.Nano() - self.nanos)
+}
+
+// ConvertSession copies a session from one format into another.
+func ConvertSession(dst SessionWriter, src SessionReader) error {
+	description, err := src.ReadDescription()
+	if err != nil {
+		return fmt.Errorf("[error] failed to read device description: %v", err)
+	}
+	if err := dst.WriteDescription(description); err != nil {
+		return fmt.Errorf("[error] failed to write device description: %v", err)
+	}
+	for {
+		event, err := src.ReadEvent()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("[error] failed to read event: %v", err)
+		}
+		if err := dst.WriteEvent(event); err != nil {
+			return fmt.Errorf("[error] failed to write event: %v", err)
+		}
+	}
+}
+
+// Record writes the description of the input event device at path, e.g.
+// "/dev/input/event3", then every event it reports until ctx is done.
+func Record(ctx context.Context, path string, session SessionWriter) error {
+	description, err := DescribeDevice(path)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteDescription(description); err != nil {
+		return fmt.Errorf("[error] failed to write device description: %v", err)
+	}
+	reader, err := OpenEventReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// NOTE: Closing the reader is the only way to interrupt a blocked read.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			reader.Close()
+		case <-done:
+		}
+	}()
+	for {
+		event, err := reader.ReadEvent()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("[error] failed to read event from %v: %v", path, err)
+		}
+		if err := session.WriteEvent(event); err != nil {
+			return fmt.Errorf("[error] failed to write event: %v", err)
+		}
+	}
+}
+
+// replaySettleTime gives userspace time to pick up the recreated device
+// before the first replayed event, otherwise the first events are lost.
+const replaySettleTime = 200 * time.Millisecond
+
+// Replay recreates the recorded device through uinput and replays the session
+// events on it. The original timing is scaled by speed, so 2 replays twice as
+// fast and 0 replays every event without delay.
+func Replay(ctx context.Context, session SessionReader, speed float64) error {
+	description, err := session.ReadDescription()
+	if err != nil {
+		return fmt.Errorf("[error] failed to read device description: %v", err)
+	}
+	device, err := description.Connect()
+	if err != nil {
+		return err
+	}
+	dev := device.(*Device)
+	defer dev.Disconnect()
+	if err := sleepContext(ctx, dev.clock(), replaySettleTime); err != nil {
+		return err
+	}
+	return ReplayOn(ctx, dev, session, speed)
+}
+
+// ReplayOn replays the session events left on device, timed on the device
+// Clock and scaled by speed as in Replay. The session description must have
+// been read already.
+func ReplayOn(ctx context.Context, device *Device, session SessionReader, speed float64) error {
+	clock := device.clock()
+	writer := &frameWriter{device: device}
+	var first time.Duration
+	var start time.Time
+	for count := 0; ; count++ {
+		event, err := session.ReadEvent()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("[error] failed to read event: %v", err)
+		}
+		offset := time.Duration(event.Time.Nano())
+		if count == 0 {
+			first, start = offset, clock.Now()
+		}
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(offset-first) / speed))
+			if err := sleepContext(ctx, clock, due.Sub(clock.Now())); err != nil {
+				return err
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := writer.Event(event); err != nil {
+			return fmt.Errorf("[error] failed to replay event: %v", err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package uinput
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInputEventJSON(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		event InputEvent
+		json  string
+	}{
+		{
+			name:  "named",
+			event: InputEvent{Time: Timeval{Sec: 1700000000, Usec: 123456}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1},
+			json:  `{"t":1700000000.123456,"type":"EV_KEY","code":"KEY_A","value":1}`,
+		},
+		{
+			name:  "negative value",
+			event: InputEvent{Time: Timeval{Usec: 1}, Type: EV_REL.Code(), Code: uint16(REL_X), Value: -10},
+			json:  `{"t":0.000001,"type":"EV_REL","code":"REL_X","value":-10}`,
+		},
+		{
+			name:  "code without a name",
+			event: InputEvent{Type: EV_KEY.Code(), Code: 0x2fe, Value: 1},
+			json:  `{"t":0,"type":"EV_KEY","code":"0x2fe","value":1}`,
+		},
+		{
+			name:  "type without a name",
+			event: InputEvent{Type: 0x1e, Code: 3, Value: 7},
+			json:  `{"t":0,"type":"30","code":"3","value":7}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.json {
+				t.Errorf("marshalled %s, want %s", data, test.json)
+			}
+			var event InputEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				t.Fatal(err)
+			}
+			if event != test.event {
+				t.Errorf("unmarshalled %+v, want %+v", event, test.event)
+			}
+		})
+	}
+}
+
+func TestInputEventJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"t":0,"type":"EV_NOPE","code":"0","value":0}`,
+		`{"t":0,"type":"EV_KEY","code":"REL_X","value":0}`,
+		`{"t":0,"type":"30","code":"KEY_A","value":0}`,
+		`{"t":0,"type":"70000","code":"0","value":0}`,
+	} {
+		var event InputEvent
+		if err := json.Unmarshal([]byte(data), &event); err == nil {
+			t.Errorf("unmarshalled %s as %+v", data, event)
+		}
+	}
+}
+
+func TestInputEventBinary(t *testing.T) {
+	event := InputEvent{Time: Timeval{Sec: 1700000000, Usec: 999999}, Type: 0x1e, Code: 0xffff, Value: -2}
+	data, err := event.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != eventBinarySize {
+		t.Errorf("marshalled %d bytes, want %d", len(data), eventBinarySize)
+	}
+	var decoded InputEvent
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != event {
+		t.Errorf("unmarshalled %+v, want %+v", decoded, event)
+	}
+	if err := decoded.UnmarshalBinary(data[1:]); err == nil {
+		t.Error("unmarshalled a short event")
+	}
+}
+
+// sessionEvents are recorded far from the epoch; every format stores them
+// relative to the first.
+var sessionEvents = []InputEvent{
+	{Time: Timeval{Sec: 1700000000, Usec: 500000}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1},
+	{Time: Timeval{Sec: 1700000000, Usec: 500000}, Type: EV_SYN.Code()},
+	{Time: Timeval{Sec: 1700000001, Usec: 1}, Type: EV_REL.Code(), Code: uint16(REL_WHEEL), Value: -120},
+	{Time: Timeval{Sec: 1700000001, Usec: 1}, Type: 0x1e, Code: 9, Value: 1 << 30},
+	{Time: Timeval{Sec: 1700000003, Usec: 250000}, Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 0},
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	for _, format := range []struct {
+		name   string
+		writer func(io.Writer) (SessionWriter, func() error)
+		reader func(io.Reader) SessionReader
+	}{
+		{
+			name: "json lines",
+			writer: func(w io.Writer) (SessionWriter, func() error) {
+				return NewJSONSessionWriter(w), func() error { return nil }
+			},
+			reader: func(r io.Reader) SessionReader { return NewJSONSessionReader(r) },
+		},
+		{
+			name: "binary",
+			writer: func(w io.Writer) (SessionWriter, func() error) {
+				writer := NewBinarySessionWriter(w)
+				return writer, writer.Flush
+			},
+			reader: func(r io.Reader) SessionReader { return NewBinarySessionReader(r) },
+		},
+		{
+			name:   "evemu",
+			writer: func(w io.Writer) (SessionWriter, func() error) { return NewEvemuWriter(w), func() error { return nil } },
+			reader: func(r io.Reader) SessionReader { return NewEvemuReader(r) },
+		},
+	} {
+		t.Run(format.name, func(t *testing.T) {
+			var out bytes.Buffer
+			writer, flush := format.writer(&out)
+			if err := writer.WriteDescription(evemuDescription); err != nil {
+				t.Fatal(err)
+			}
+			for _, event := range sessionEvents {
+				if err := writer.WriteEvent(event); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := flush(); err != nil {
+				t.Fatal(err)
+			}
+			reader := format.reader(&out)
+			description, err := reader.ReadDescription()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(description, evemuDescription) {
+				t.Errorf("read back %+v, want %+v", description, evemuDescription)
+			}
+			start := sessionEvents[0].Time.Nano()
+			for n, want := range sessionEvents {
+				want.Time = NsecToTimeval(want.Time.Nano() - start)
+				event, err := reader.ReadEvent()
+				if err != nil {
+					t.Fatalf("event %d: %v", n, err)
+				}
+				if event != want {
+					t.Errorf("event %d read as %+v, want %+v", n, event, want)
+				}
+			}
+			if _, err := reader.ReadEvent(); err != io.EOF {
+				t.Errorf("read past the last event: %v", err)
+			}
+		})
+	}
+}
+
+func TestBinarySessionErrors(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewBinarySessionWriter(&out)
+	writer.WriteDescription(evemuDescription)
+	writer.WriteEvent(InputEvent{Type: EV_KEY.Code(), Code: uint16(KEY_A), Value: 1})
+	writer.Flush()
+	data := out.Bytes()
+
+	reader := NewBinarySessionReader(bytes.NewReader(data[:len(data)-1]))
+	if _, err := reader.ReadDescription(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadEvent(); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("read a truncated record with %v", err)
+	}
+	if _, err := NewBinarySessionReader(strings.NewReader("UIEVLOX\x01")).ReadDescription(); err == nil {
+		t.Error("read a log with the wrong magic")
+	}
+	if _, err := NewBinarySessionReader(strings.NewReader("UIEVLOG\x02")).ReadDescription(); err == nil {
+		t.Error("read a log of an unknown version")
+	}
+	if _, err := NewBinarySessionReader(bytes.NewReader(nil)).ReadEvent(); !errors.Is(err, io.EOF) {
+		t.Errorf("reading an empty log failed with %v, want io.EOF", err)
+	}
+}
+
+func TestConvertSession(t *testing.T) {
+	var binary, evemu bytes.Buffer
+	writer := NewBinarySessionWriter(&binary)
+	writer.WriteDescription(evemuDescription)
+	for _, event := range sessionEvents {
+		writer.WriteEvent(event)
+	}
+	writer.Flush()
+	if err := ConvertSession(NewEvemuWriter(&evemu), NewBinarySessionReader(&binary)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(evemu.String(), "E: 2.750000 0001 001e 0000\n") {
+		t.Errorf("converted to:\n%s", evemu.String())
+	}
+}
@@ -0,0 +1,204 @@
+package uinput
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RedundantEvents decides what happens to events that would not change the
+// device state, such as pressing a key already down or moving an axis to
+// its current value. The input core drops them either way.
+type RedundantEvents int
+
+const (
+	// PassRedundant writes redundant events as they are.
+	PassRedundant RedundantEvents = iota
+	// IgnoreRedundant leaves redundant events out of their frame, and skips
+	// frames left empty.
+	IgnoreRedundant
+	// RejectRedundant fails the frame with ErrRedundantEvent, writing nothing.
+	RejectRedundant
+)
+
+// ErrRedundantEvent is returned for redundant events under RejectRedundant,
+// wrapped by the methods writing them.
+var ErrRedundantEvent = errors.New("[error] event does not change the device state")
+
+// DeviceState is the state of a virtual device as reported by the frames
+// written to it.
+type DeviceState struct {
+	// Pressed are the keys and buttons down, in the order they were pressed.
+	Pressed []EventCode
+	// Abs are the values of the absolute axes, multitouch ones aside.
+	Abs      map[EventCode]int32
+	Switches map[EventCode]bool
+	// Slot is the current multitouch slot and Touches the slots in contact.
+	Slot    int32
+	Touches map[int32]TouchState
+}
+
+// TouchState is a multitouch slot in contact, with its ABS_MT_ values.
+type TouchState struct {
+	TrackingID int32
+	Abs        map[EventCode]int32
+}
+
+func newDeviceState() DeviceState {
+	return DeviceState{
+		Abs:      make(map[EventCode]int32),
+		Switches: make(map[EventCode]bool),
+		Touches:  make(map[int32]TouchState),
+	}
+}
+
+func isMultitouch(code EventCode) bool {
+	return code >= ABS_MT_SLOT && code <= ABS_MT_TOOL_Y
+}
+
+func (self *DeviceState) IsPressed(code EventCode) bool {
+	for _, pressed := range self.Pressed {
+		if pressed == code {
+			return true
+		}
+	}
+	return false
+}
+
+// redundant reports whether the event would leave the state as it is.
+func (self *DeviceState) redundant(event InputEvent) bool {
+	code := EventCode(event.Code)
+	switch event.Type {
+	case EV_KEY.Code():
+		if event.Value == int32(KeyPressed.Code()) {
+			return self.IsPressed(code)
+		}
+		return !self.IsPressed(code)
+	case EV_ABS.Code():
+		if isMultitouch(code) {
+			return false
+		}
+		value, ok := self.Abs[code]
+		return ok && value == event.Value
+	case EV_SW.Code():
+		state, ok := self.Switches[code]
+		return ok && state == (event.Value != 0)
+	}
+	return false
+}
+
+// filter applies policy to the redundant events of a frame.
+func (self *DeviceState) filter(events []InputEvent, policy RedundantEvents) ([]InputEvent, error) {
+	if policy == PassRedundant {
+		return events, nil
+	}
+	// NOTE: The frame is checked against the state it builds up to each
+	// event, so pressing and releasing a key in one frame is not redundant.
+	state := self.Snapshot()
+	filtered := make([]InputEvent, 0, len(events))
+	for _, event := range events {
+		if !state.redundant(event) {
+			filtered = append(filtered, event)
+			state.track([]InputEvent{event})
+			continue
+		}
+		if policy == RejectRedundant {
+			return nil, fmt.Errorf("%w: type %d code %d value %d", ErrRedundantEvent, event.Type, event.Code, event.Value)
+		}
+		// NOTE: A scan code reported for a dropped key goes with it.
+		if last := len(filtered) - 1; event.Type == EV_KEY.Code() && last >= 0 &&
+			filtered[last].Type == EV_MSC.Code() && filtered[last].Code == uint16(MSC_SCAN) {
+			filtered = filtered[:last]
+		}
+	}
+	return filtered, nil
+}
+
+func (self *DeviceState) track(events []InputEvent) {
+	if self.Abs == nil {
+		*self = newDeviceState()
+	}
+	for _, event := range events {
+		code := EventCode(event.Code)
+		switch event.Type {
+		case EV_KEY.Code():
+			if event.Value == int32(KeyReleased.Code()) {
+				self.release(code)
+			} else if event.Value == int32(KeyPressed.Code()) && !self.IsPressed(code) {
+				self.Pressed = append(self.Pressed, code)
+			}
+		case EV_SW.Code():
+			self.Switches[code] = event.Value != 0
+		case EV_ABS.Code():
+			self.trackAbs(code, event.Value)
+		}
+	}
+}
+
+func (self *DeviceState) trackAbs(code EventCode, value int32) {
+	switch {
+	case code == ABS_MT_SLOT:
+		self.Slot = value
+	case code == ABS_MT_TRACKING_ID && value < 0:
+		delete(self.Touches, self.Slot)
+	case code == ABS_MT_TRACKING_ID:
+		self.Touches[self.Slot] = TouchState{TrackingID: value, Abs: make(map[EventCode]int32)}
+	case isMultitouch(code):
+		if touch, ok := self.Touches[self.Slot]; ok {
+			touch.Abs[code] = value
+		}
+	default:
+		self.Abs[code] = value
+	}
+}
+
+func (self *DeviceState) release(code EventCode) {
+	for n, pressed := range self.Pressed {
+		if pressed == code {
+			self.Pressed = append(self.Pressed[:n], self.Pressed[n+1:]...)
+			return
+		}
+	}
+}
+
+// Snapshot returns a deep copy of the state.
+func (self *DeviceState) Snapshot() DeviceState {
+	snapshot := newDeviceState()
+	snapshot.Pressed = append([]EventCode(nil), self.Pressed...)
+	snapshot.Slot = self.Slot
+	for code, value := range self.Abs {
+		snapshot.Abs[code] = value
+	}
+	for code, state := range self.Switches {
+		snapshot.Switches[code] = state
+	}
+	for slot, touch := range self.Touches {
+		copied := TouchState{TrackingID: touch.TrackingID, Abs: make(map[EventCode]int32)}
+		for code, value := range touch.Abs {
+			copied.Abs[code] = value
+		}
+		snapshot.Touches[slot] = copied
+	}
+	return snapshot
+}
+
+// IsPressed reports whether a key or button of the device is down.
+func (self *Device) IsPressed(code EventCode) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.state.IsPressed(code)
+}
+
+// AbsValue returns the last value reported on an absolute axis, 0 when none
+// was reported yet.
+func (self *Device) AbsValue(axis EventCode) int32 {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.state.Abs[axis]
+}
+
+// Snapshot returns a copy of the current state of the device.
+func (self *Device) Snapshot() DeviceState {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.state.Snapshot()
+}
@@ -0,0 +1,96 @@
+package uinput
+
+import (
+	"fmt"
+)
+
+// NewSwitchDevice creates and connects a virtual device reporting the given
+// switches (SW_LID, SW_TABLET_MODE, SW_HEADPHONE_INSERT, SW_DOCK, ...) with
+// their initial state, e.g. to drive lid or tablet mode handling in logind and
+// desktop environments on machines without such hardware.
+func NewSwitchDevice(name string, switches map[EventCode]bool) (VirtualDevice, error) {
+	device, err := Switch.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	dev := device.(*Device)
+	for code, state := range switches {
+		dev.Switches[code] = state
+	}
+	return dev.Connect()
+}
+
+func (self *Device) RegisterSwitches() error {
+	if len(self.Switches) == 0 {
+		self.FD.Close()
+		return fmt.Errorf("[error] switch device declares no switches")
+	}
+	if err := self.newEventSource(EV_SW); err != nil {
+		self.FD.Close()
+		return fmt.Errorf("[error] failed to register switch input device: %v", err)
+	}
+	for code := range self.Switches {
+		if err := ioctl(self.FD, SwitchBit.Code(), uintptr(code)); err != nil {
+			self.FD.Close()
+			return fmt.Errorf("[error] failed to register switch %d: %v", code, err)
+		}
+	}
+	return nil
+}
+
+func (self *Device) reportSwitches() error {
+	var events []InputEvent
+	for code, state := range self.Switches {
+		events = append(events, switchInputEvent(code, state))
+	}
+	if err := self.writeFrame(events...); err != nil {
+		return fmt.Errorf("[error] failed to report switches: %v", err)
+	}
+	return nil
+}
+
+// SwitchState returns the last reported state of a declared switch.
+func (self *Device) SwitchState(code EventCode) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.Switches[code]
+}
+
+func (self *Device) SetSwitch(code EventCode, state bool) error {
+	self.frames.Lock()
+	defer self.frames.Unlock()
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.setSwitchLocked(code, state)
+}
+
+// ToggleSwitch flips a switch; concurrent toggles each apply in turn.
+func (self *Device) ToggleSwitch(code EventCode) error {
+	self.frames.Lock()
+	defer self.frames.Unlock()
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.setSwitchLocked(code, !self.Switches[code])
+}
+
+func (self *Device) setSwitchLocked(code EventCode, state bool) error {
+	if _, ok := self.Switches[code]; !ok {
+		return fmt.Errorf("[error] switch %d was not declared on this device", code)
+	}
+	if err := self.writeFrameLocked(switchInputEvent(code, state)); err != nil {
+		return fmt.Errorf("[error] failed to write switch event to device file: %w", err)
+	}
+	self.Switches[code] = state
+	return nil
+}
+
+func switchInputEvent(code EventCode, state bool) InputEvent {
+	event := InputEvent{
+		Type: switchEvent.UInt16(),
+		Code: uint16(code),
+	}
+	if state {
+		event.Value = 1
+	}
+	return event
+}
@@ -0,0 +1,193 @@
+package uinput
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// DefaultSpinTime is how long before an action the timeline stops sleeping
+// and polls the clock instead; timers alone are only accurate to about a
+// millisecond under load.
+const DefaultSpinTime = 2 * time.Millisecond
+
+// TimelineAction is an action run at an offset from the start of a timeline.
+type TimelineAction struct {
+	At   time.Duration
+	Name string
+	Run  func() error
+}
+
+// Timeline runs actions across any number of devices at fixed offsets from
+// its start, e.g. to click exactly 3ms after a key goes down:
+//
+//	timeline := &Timeline{}
+//	timeline.PressKey(0, keyboard, KEY_LEFTSHIFT)
+//	timeline.PressButton(3*time.Millisecond, mouse, LeftButton)
+//	stats, err := timeline.Run(ctx)
+//
+// Offsets are measured on the Clock, the monotonic clock by default. Actions are run one at a time
+// in the order of their offsets, those sharing one in the order added, so
+// an action running late delays the ones after it.
+type Timeline struct {
+	// SpinTime overrides DefaultSpinTime; negative values never spin.
+	SpinTime time.Duration
+	// Clock times the actions, SystemClock when nil.
+	Clock Clock
+
+	actions []TimelineAction
+}
+
+// Add schedules action at offset at from the start of the timeline.
+func (self *Timeline) Add(at time.Duration, name string, action func() error) *Timeline {
+	self.actions = append(self.actions, TimelineAction{At: at, Name: name, Run: action})
+	return self
+}
+
+func (self *Timeline) PressKey(at time.Duration, device *Device, key EventCode) *Timeline {
+	return self.Add(at, fmt.Sprintf("press %v", CodeName(EV_KEY, key)), func() error {
+		return device.PressKey(key)
+	})
+}
+
+func (self *Timeline) ReleaseKey(at time.Duration, device *Device, key EventCode) *Timeline {
+	return self.Add(at, fmt.Sprintf("release %v", CodeName(EV_KEY, key)), func() error {
+		return device.ReleaseKey(key)
+	})
+}
+
+func (self *Timeline) PressButton(at time.Duration, device *Device, button ButtonType) *Timeline {
+	return self.Add(at, fmt.Sprintf("press %v", button), func() error {
+		return device.PressButton(button)
+	})
+}
+
+func (self *Timeline) ReleaseButton(at time.Duration, device *Device, button ButtonType) *Timeline {
+	return self.Add(at, fmt.Sprintf("release %v", button), func() error {
+		return device.ReleaseButton(button)
+	})
+}
+
+// Frame schedules a frame of raw events on a device, for gamepads and other
+// devices without a dedicated method.
+func (self *Timeline) Frame(at time.Duration, device *Device, events ...InputEvent) *Timeline {
+	return self.Add(at, fmt.Sprintf("frame of %d events", len(events)), func() error {
+		return device.writeFrame(events...)
+	})
+}
+
+// RawEvent schedules an event on a RawEventWriter; it is up to the caller to
+// schedule the SYN_REPORT closing the frame.
+func (self *Timeline) RawEvent(at time.Duration, writer *RawEventWriter, eventType EventType, code EventCode, value int32) *Timeline {
+	return self.Add(at, fmt.Sprintf("%v %v", CodeName(eventType, code), value), func() error {
+		return writer.Event(eventType, code, value)
+	})
+}
+
+// TimelineStats reports how late the actions of a timeline ran.
+type TimelineStats struct {
+	// Drifts are how late each action started, in the order they ran.
+	Drifts []time.Duration
+	Mean   time.Duration
+	Max    time.Duration
+	StdDev time.Duration
+}
+
+// Percentile returns the drift p percent of the actions stayed within.
+func (self TimelineStats) Percentile(p float64) time.Duration {
+	if len(self.Drifts) == 0 {
+		return 0
+	}
+	drifts := append([]time.Duration(nil), self.Drifts...)
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i] < drifts[j] })
+	n := int(math.Ceil(p/100*float64(len(drifts)))) - 1
+	if n < 0 {
+		n = 0
+	} else if n >= len(drifts) {
+		n = len(drifts) - 1
+	}
+	return drifts[n]
+}
+
+func (self TimelineStats) String() string {
+	return fmt.Sprintf("%d actions, drift mean %v, max %v, stddev %v",
+		len(self.Drifts), self.Mean, self.Max, self.StdDev)
+}
+
+func newTimelineStats(drifts []time.Duration) TimelineStats {
+	stats := TimelineStats{Drifts: drifts}
+	if len(drifts) == 0 {
+		return stats
+	}
+	var sum float64
+	for _, drift := range drifts {
+		sum += float64(drift)
+		if drift > stats.Max {
+			stats.Max = drift
+		}
+	}
+	mean := sum / float64(len(drifts))
+	var variance float64
+	for _, drift := range drifts {
+		variance += (float64(drift) - mean) * (float64(drift) - mean)
+	}
+	stats.Mean = time.Duration(mean)
+	stats.StdDev = time.Duration(math.Sqrt(variance / float64(len(drifts))))
+	return stats
+}
+
+// Run runs the actions from now until the last one or ctx is done, stopping
+// at the first action failing. The stats cover the actions run either way.
+func (self *Timeline) Run(ctx context.Context) (TimelineStats, error) {
+	actions := append([]TimelineAction(nil), self.actions...)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].At < actions[j].At })
+	spin := self.SpinTime
+	if spin == 0 {
+		spin = DefaultSpinTime
+	}
+	clock := self.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	drifts := make([]time.Duration, 0, len(actions))
+	// NOTE: time.Now carries a monotonic reading, so offsets from start are
+	// immune to wall clock changes.
+	start := clock.Now()
+	for _, action := range actions {
+		if err := waitUntil(ctx, clock, start.Add(action.At), spin); err != nil {
+			return newTimelineStats(drifts), err
+		}
+		drifts = append(drifts, clock.Now().Sub(start)-action.At)
+		if err := action.Run(); err != nil {
+			return newTimelineStats(drifts), fmt.Errorf("[error] timeline action %q at %v failed: %v", action.Name, action.At, err)
+		}
+	}
+	return newTimelineStats(drifts), nil
+}
+
+// waitUntil sleeps until spin before deadline, then polls the clock. Clocks
+// other than SystemClock sleep all the way to deadline instead.
+func waitUntil(ctx context.Context, clock Clock, deadline time.Time, spin time.Duration) error {
+	if clock != SystemClock {
+		return sleepContext(ctx, clock, deadline.Sub(clock.Now()))
+	}
+	if sleep := time.Until(deadline) - spin; sleep > 0 {
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		runtime.Gosched()
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,109 @@
+package uinput
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// TraceLogger receives traced frames; *slog.Logger satisfies it.
+type TraceLogger interface {
+	Info(msg string, args ...any)
+}
+
+// Trace shows the frames written to a device in a readable form, one event
+// per line, to review what a macro or remap would do before letting it loose:
+//
+//	KEY_A pressed
+//	SYN_REPORT
+//	REL_X -10
+//	SYN_REPORT
+//
+// Frames are traced once past the redundant event policy and the limits, as
+// they reach the device.
+type Trace struct {
+	Writer io.Writer
+	// Logger receives each frame as one record, with its events joined by
+	// commas and the device name as the "device" attribute.
+	Logger TraceLogger
+	// DryRun traces frames instead of writing them to the device.
+	DryRun bool
+}
+
+func (self Trace) enabled() bool {
+	return self.Writer != nil || self.Logger != nil
+}
+
+func (self Trace) frame(device string, events []InputEvent) error {
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, FormatEvent(event))
+	}
+	if self.Logger != nil {
+		self.Logger.Info(strings.Join(lines, ", "), "device", device)
+	}
+	if self.Writer != nil {
+		if _, err := io.WriteString(self.Writer, strings.Join(lines, "\n")+"\n"); err != nil {
+			return fmt.Errorf("[error] failed to write trace: %v", err)
+		}
+	}
+	return nil
+}
+
+// FormatEvent describes an event by the names of its type and code, e.g.
+// "KEY_A pressed", "REL_X -10" or "SYN_REPORT".
+func FormatEvent(event InputEvent) string {
+	eventType, ok := event.eventType()
+	if !ok {
+		return fmt.Sprintf("type 0x%02x code 0x%02x %d", event.Type, event.Code, event.Value)
+	}
+	name := CodeName(eventType, EventCode(event.Code))
+	switch eventType {
+	case EV_SYN:
+		return name
+	case EV_KEY:
+		switch event.Value {
+		case int32(KeyReleased.Code()):
+			return name + " released"
+		case int32(KeyPressed.Code()):
+			return name + " pressed"
+		case keyRepeat:
+			return name + " repeated"
+		}
+	case EV_SW, EV_LED:
+		if event.Value == 0 {
+			return name + " off"
+		}
+		return name + " on"
+	}
+	return fmt.Sprintf("%v %d", name, event.Value)
+}
+
+func (self *Device) name() string {
+	return string(bytes.TrimRight(self.Name[:], "\x00"))
+}
+
+// DryRun returns a device of the type tracing its frames to trace instead of
+// writing them; it needs neither /dev/uinput nor Connect, and Disconnect only
+// releases what is held.
+func (devType DeviceType) DryRun(name string, trace Trace) (*Device, error) {
+	// NOTE: FD being set is what tells a connected device apart.
+	deviceFD, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("[error] could not open %v: %v", os.DevNull, err)
+	}
+	trace.DryRun = true
+	device := &Device{
+		Name:     DeviceName(name).Bytes(),
+		Type:     devType,
+		FD:       deviceFD,
+		Switches: make(map[EventCode]bool),
+		Trace:    trace,
+	}
+	if devType == Keyboard {
+		device.Keyboard = newVirtualKeyboard()
+	}
+	return device, nil
+}
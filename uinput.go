@@ -1,10 +1,5 @@
 package uinput
 
-import (
-	"fmt"
-	"strconv"
-)
-
 type DeviceProperty uint16
 
 // ref:https://github.com/torvalds/linux/blob/master/include/uapi/linux/uinput.h
@@ -50,26 +45,9 @@ func (st SyncType) String() string {
 	}
 }
 
+// SyncType values follow the SYN_ codes of input-event-codes.h.
 func (st SyncType) Code() uint32 {
-	// NOTE:DEV
-	fmt.Printf("0x%v", st)
-
-	code, _ := strconv.Atoi(st.String())
-
-	return uint32(code)
-	// TODO: Could do fmt.Sprintf("0x%X", st) so this could all be 1 line
-	//switch st {
-	//case ReportSync:
-	//	return 0x0
-	//case ConfigSync:
-	//	return 0x1
-	//case MultiTouchReportSync:
-	//	return 0x2
-	//case DroppedSync:
-	//	return 0x3
-	//default:
-	//	return 0
-	//}
+	return uint32(st)
 }
 
 func (st SyncType) Int32() int32 {
@@ -0,0 +1,59 @@
+package usb
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"sync"
+)
+
+//go:generate go run ../cmd/parse-usb-ids -input /usr/share/hwdata/usb.ids -output usb.ids.gz
+
+// embeddedIds is usb.ids as written by Database.WriteTo, gzipped. It is only
+// decompressed and parsed on first use.
+//
+//go:embed usb.ids.gz
+var embeddedIds []byte
+
+var embedded struct {
+	once     sync.Once
+	database *Database
+}
+
+// Embedded returns the usb ids shipped with the package.
+func Embedded() *Database {
+	embedded.once.Do(func() {
+		reader, err := gzip.NewReader(bytes.NewReader(embeddedIds))
+		if err != nil {
+			panic(fmt.Sprintf("[error] corrupt embedded usb ids: %v", err))
+		}
+		if embedded.database, err = Parse(reader); err != nil {
+			panic(fmt.Sprintf("[error] corrupt embedded usb ids: %v", err))
+		}
+	})
+	return embedded.database
+}
+
+// USBDevice is a product of the usb ids with its ids in hex.
+type USBDevice struct {
+	ProductID   string
+	ProductName string
+	VendorID    string
+	VendorName  string
+}
+
+// USBDevices lists every product of the embedded usb ids.
+func USBDevices() []USBDevice {
+	products := Embedded().sorted
+	devices := make([]USBDevice, 0, len(products))
+	for _, product := range products {
+		devices = append(devices, USBDevice{
+			ProductID:   fmt.Sprintf("%04x", product.ID),
+			ProductName: product.Name,
+			VendorID:    fmt.Sprintf("%04x", product.VendorID),
+			VendorName:  product.VendorName,
+		})
+	}
+	return devices
+}
@@ -0,0 +1,92 @@
+package usb
+
+import (
+	"strings"
+)
+
+// Vendor is a USB vendor along with the products listed for it.
+type Vendor struct {
+	ID       uint16
+	Name     string
+	Products []Product
+}
+
+// Product is a USB product, identified by its vendor and product ids.
+type Product struct {
+	VendorID   uint16
+	ID         uint16
+	Name       string
+	VendorName string
+	// Interfaces names the product interfaces listed, by interface number.
+	Interfaces map[uint8]string `json:",omitempty"`
+}
+
+func (self *Database) LookupVendor(vendorID uint16) (Vendor, bool) {
+	vendor, ok := self.Vendors[vendorID]
+	if !ok {
+		return Vendor{}, false
+	}
+	return *vendor, true
+}
+
+func (self *Database) LookupProduct(vendorID, productID uint16) (Product, bool) {
+	product, ok := self.products[productKey(vendorID, productID)]
+	return product, ok
+}
+
+// Search returns the products whose vendor or product name contains every
+// word of query, ignoring case, ordered by vendor and product id.
+func (self *Database) Search(query string) []Product {
+	words := strings.Fields(strings.ToLower(query))
+	var products []Product
+	for _, product := range self.sorted {
+		name := strings.ToLower(product.VendorName + " " + product.Name)
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(name, word) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// ProductsNamed returns the products whose name contains any of keywords,
+// ignoring case, e.g. ProductsNamed("mouse") for real mice.
+func (self *Database) ProductsNamed(keywords ...string) []Product {
+	var products []Product
+	for _, product := range self.sorted {
+		name := strings.ToLower(product.Name)
+		for _, keyword := range keywords {
+			if strings.Contains(name, strings.ToLower(keyword)) {
+				products = append(products, product)
+				break
+			}
+		}
+	}
+	return products
+}
+
+// LookupVendor looks a vendor up in the embedded usb ids.
+func LookupVendor(vendorID uint16) (Vendor, bool) {
+	return Embedded().LookupVendor(vendorID)
+}
+
+// LookupProduct looks a product up in the embedded usb ids.
+func LookupProduct(vendorID, productID uint16) (Product, bool) {
+	return Embedded().LookupProduct(vendorID, productID)
+}
+
+// Search searches the embedded usb ids, see Database.Search.
+func Search(query string) []Product {
+	return Embedded().Search(query)
+}
+
+// ProductsNamed searches the embedded usb ids, see Database.ProductsNamed.
+func ProductsNamed(keywords ...string) []Product {
+	return Embedded().ProductsNamed(keywords...)
+}
@@ -0,0 +1,353 @@
+package usb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The usb.ids format, maintained at http://www.linux-usb.org/usb-ids.html and
+// shipped by hwdata and usbutils. Entries are a hex id, two spaces and a name;
+// a tab nests an entry under the one before it:
+//
+//	046d  Logitech, Inc.
+//		c52b  Unifying Receiver
+//			00  Keyboard
+//	C 03  Human Interface Device
+//		01  Boot Interface Subclass
+//			01  Keyboard
+//	HUT 01  Generic Desktop Controls
+//		002  Mouse
+//	HCC 33  US
+//
+// Country codes alone are decimal.
+// The audio and video terminal types, HID descriptor and report item types,
+// physical descriptor items and languages are skipped.
+
+// SystemPaths are where distributions install usb.ids, in order of preference.
+var SystemPaths = []string{
+	"/usr/share/hwdata/usb.ids",
+	"/usr/share/misc/usb.ids",
+	"/var/lib/usbutils/usb.ids",
+	"/usr/share/usb.ids",
+}
+
+// Class is a USB device or interface class with its subclasses.
+type Class struct {
+	ID         uint8
+	Name       string
+	Subclasses map[uint8]*Subclass
+}
+
+type Subclass struct {
+	ID        uint8
+	Name      string
+	Protocols map[uint8]string
+}
+
+// UsagePage is a page of the HID usage tables with the usages it names.
+type UsagePage struct {
+	ID     uint16
+	Name   string
+	Usages map[uint16]string
+}
+
+// Database holds the contents of a usb.ids file.
+type Database struct {
+	Vendors      map[uint16]*Vendor
+	Classes      map[uint8]*Class
+	UsagePages   map[uint16]*UsagePage
+	CountryCodes map[uint8]string
+
+	products map[uint32]Product
+	sorted   []Product
+}
+
+func newDatabase() *Database {
+	return &Database{
+		Vendors:      make(map[uint16]*Vendor),
+		Classes:      make(map[uint8]*Class),
+		UsagePages:   make(map[uint16]*UsagePage),
+		CountryCodes: make(map[uint8]string),
+	}
+}
+
+// Load parses the usb.ids file at path.
+func Load(path string) (*Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// LoadSystem parses the first usb.ids file found in SystemPaths, which is
+// usually more recent than the embedded one.
+func LoadSystem() (*Database, error) {
+	for _, path := range SystemPaths {
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	return nil, fmt.Errorf("[error] no usb.ids found in %v", strings.Join(SystemPaths, ", "))
+}
+
+// usbIdsParser tracks the entries the lines being parsed nest under.
+type usbIdsParser struct {
+	database *Database
+	line     int
+	section  string // keyword of the current top level entry, "" for vendors
+	vendor   *Vendor
+	product  *Product
+	class    *Class
+	subclass *Subclass
+	page     *UsagePage
+}
+
+func (self *usbIdsParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("[error] usb.ids line %d: %v", self.line, fmt.Sprintf(format, args...))
+}
+
+// Parse reads a database in the usb.ids format.
+func Parse(r io.Reader) (*Database, error) {
+	parser := &usbIdsParser{database: newDatabase()}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parser.line++
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		if err := parser.parseLine(depth, line[depth:]); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	parser.database.index()
+	return parser.database, nil
+}
+
+// splitEntry splits an entry into its hex id, of at most bits, and its name.
+func (self *usbIdsParser) splitEntry(entry string, bits int) (uint64, string, error) {
+	id, name, found := strings.Cut(entry, " ")
+	if !found {
+		return 0, "", self.errorf("expected an id and a name, found %q", entry)
+	}
+	value, err := strconv.ParseUint(id, 16, bits)
+	if err != nil {
+		return 0, "", self.errorf("invalid id %q", id)
+	}
+	return value, strings.TrimSpace(name), nil
+}
+
+func (self *usbIdsParser) parseLine(depth int, entry string) error {
+	if depth == 0 {
+		self.section = ""
+		// NOTE: Vendor ids are four hex digits, class lines start with "C"
+		// which would pass for hex too.
+		if keyword, rest, found := strings.Cut(entry, " "); found && !(len(keyword) == 4 && isHex(keyword)) {
+			self.section, entry = keyword, rest
+		}
+	}
+	switch self.section {
+	case "":
+		return self.parseVendor(depth, entry)
+	case "C":
+		return self.parseClass(depth, entry)
+	case "HUT":
+		return self.parseUsage(depth, entry)
+	case "HCC":
+		if depth == 0 {
+			id, name, found := strings.Cut(entry, " ")
+			code, err := strconv.ParseUint(id, 10, 8)
+			if !found || err != nil {
+				return self.errorf("invalid country code %q", entry)
+			}
+			self.database.CountryCodes[uint8(code)] = strings.TrimSpace(name)
+		}
+	}
+	return nil
+}
+
+func (self *usbIdsParser) parseVendor(depth int, entry string) error {
+	switch depth {
+	case 0:
+		id, name, err := self.splitEntry(entry, 16)
+		if err != nil {
+			return err
+		}
+		self.vendor, self.product = &Vendor{ID: uint16(id), Name: name}, nil
+		self.database.Vendors[self.vendor.ID] = self.vendor
+	case 1:
+		if self.vendor == nil {
+			return self.errorf("product outside of a vendor")
+		}
+		id, name, err := self.splitEntry(entry, 16)
+		if err != nil {
+			return err
+		}
+		self.vendor.Products = append(self.vendor.Products, Product{
+			VendorID:   self.vendor.ID,
+			ID:         uint16(id),
+			Name:       name,
+			VendorName: self.vendor.Name,
+		})
+		self.product = &self.vendor.Products[len(self.vendor.Products)-1]
+	case 2:
+		if self.product == nil {
+			return self.errorf("interface outside of a product")
+		}
+		id, name, err := self.splitEntry(entry, 8)
+		if err != nil {
+			return err
+		}
+		if self.product.Interfaces == nil {
+			self.product.Interfaces = make(map[uint8]string)
+		}
+		self.product.Interfaces[uint8(id)] = name
+	}
+	return nil
+}
+
+func (self *usbIdsParser) parseClass(depth int, entry string) error {
+	switch depth {
+	case 0:
+		id, name, err := self.splitEntry(entry, 8)
+		if err != nil {
+			return err
+		}
+		self.class = &Class{ID: uint8(id), Name: name, Subclasses: make(map[uint8]*Subclass)}
+		self.subclass = nil
+		self.database.Classes[self.class.ID] = self.class
+	case 1:
+		id, name, err := self.splitEntry(entry, 8)
+		if err != nil {
+			return err
+		}
+		self.subclass = &Subclass{ID: uint8(id), Name: name, Protocols: make(map[uint8]string)}
+		self.class.Subclasses[self.subclass.ID] = self.subclass
+	case 2:
+		if self.subclass == nil {
+			return self.errorf("protocol outside of a subclass")
+		}
+		id, name, err := self.splitEntry(entry, 8)
+		if err != nil {
+			return err
+		}
+		self.subclass.Protocols[uint8(id)] = name
+	}
+	return nil
+}
+
+func (self *usbIdsParser) parseUsage(depth int, entry string) error {
+	id, name, err := self.splitEntry(entry, 16)
+	if err != nil {
+		return err
+	}
+	if depth == 0 {
+		self.page = &UsagePage{ID: uint16(id), Name: name, Usages: make(map[uint16]string)}
+		self.database.UsagePages[self.page.ID] = self.page
+	} else {
+		self.page.Usages[uint16(id)] = name
+	}
+	return nil
+}
+
+func isHex(value string) bool {
+	_, err := strconv.ParseUint(value, 16, 64)
+	return err == nil
+}
+
+// index builds the product lookups once the database is parsed.
+func (self *Database) index() {
+	self.products = make(map[uint32]Product)
+	self.sorted = nil
+	for _, vendor := range self.Vendors {
+		sort.Slice(vendor.Products, func(i, j int) bool { return vendor.Products[i].ID < vendor.Products[j].ID })
+		for _, product := range vendor.Products {
+			self.products[productKey(product.VendorID, product.ID)] = product
+			self.sorted = append(self.sorted, product)
+		}
+	}
+	sort.Slice(self.sorted, func(i, j int) bool {
+		return productKey(self.sorted[i].VendorID, self.sorted[i].ID) < productKey(self.sorted[j].VendorID, self.sorted[j].ID)
+	})
+}
+
+func productKey(vendorID, productID uint16) uint32 {
+	return uint32(vendorID)<<16 | uint32(productID)
+}
+
+// WriteTo writes the database back in the usb.ids format, sorted by id and
+// without the skipped sections.
+func (self *Database) WriteTo(w io.Writer) (int64, error) {
+	out := &countingWriter{w: bufio.NewWriter(w)}
+	for _, vendorID := range sortedKeys(self.Vendors) {
+		vendor := self.Vendors[vendorID]
+		fmt.Fprintf(out, "%04x  %v\n", vendor.ID, vendor.Name)
+		for _, product := range vendor.Products {
+			fmt.Fprintf(out, "\t%04x  %v\n", product.ID, product.Name)
+			for _, id := range sortedKeys(product.Interfaces) {
+				fmt.Fprintf(out, "\t\t%02x  %v\n", id, product.Interfaces[id])
+			}
+		}
+	}
+	for _, classID := range sortedKeys(self.Classes) {
+		class := self.Classes[classID]
+		fmt.Fprintf(out, "C %02x  %v\n", class.ID, class.Name)
+		for _, subclassID := range sortedKeys(class.Subclasses) {
+			subclass := class.Subclasses[subclassID]
+			fmt.Fprintf(out, "\t%02x  %v\n", subclass.ID, subclass.Name)
+			for _, id := range sortedKeys(subclass.Protocols) {
+				fmt.Fprintf(out, "\t\t%02x  %v\n", id, subclass.Protocols[id])
+			}
+		}
+	}
+	for _, pageID := range sortedKeys(self.UsagePages) {
+		page := self.UsagePages[pageID]
+		fmt.Fprintf(out, "HUT %02x  %v\n", page.ID, page.Name)
+		for _, id := range sortedKeys(page.Usages) {
+			fmt.Fprintf(out, "\t%03x  %v\n", id, page.Usages[id])
+		}
+	}
+	for _, id := range sortedKeys(self.CountryCodes) {
+		fmt.Fprintf(out, "HCC %02d  %v\n", id, self.CountryCodes[id])
+	}
+	if out.err != nil {
+		return out.count, out.err
+	}
+	return out.count, out.w.Flush()
+}
+
+type countingWriter struct {
+	w     *bufio.Writer
+	count int64
+	err   error
+}
+
+func (self *countingWriter) Write(data []byte) (int, error) {
+	if self.err != nil {
+		return 0, self.err
+	}
+	n, err := self.w.Write(data)
+	self.count += int64(n)
+	self.err = err
+	return n, err
+}
+
+func sortedKeys[K uint8 | uint16, V any](values map[K]V) []K {
+	keys := make([]K, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,132 @@
+package usb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fixtureIds is testdata/usb.ids as WriteTo writes it back: sorted, without
+// comments or the skipped sections.
+const fixtureIds = `046d  Logitech, Inc.
+	0a29  H600 [Wireless Headset]
+	c52b  Unifying Receiver
+		00  Keyboard
+		02  Mouse
+1d6b  Linux Foundation
+	0002  2.0 root hub
+C 03  Human Interface Device
+	00  No Subclass
+	01  Boot Interface Subclass
+		01  Keyboard
+		02  Mouse
+C 09  Hub
+HUT 01  Generic Desktop Controls
+	000  Undefined
+	002  Mouse
+	006  Keyboard
+HCC 00  Not supported
+HCC 33  US
+`
+
+func TestParseFixture(t *testing.T) {
+	database, err := Load("testdata/usb.ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vendor, ok := database.LookupVendor(0x046d); !ok || vendor.Name != "Logitech, Inc." || len(vendor.Products) != 2 {
+		t.Errorf("vendor 046d is %+v", vendor)
+	}
+	product, ok := database.LookupProduct(0x046d, 0xc52b)
+	want := Product{VendorID: 0x046d, ID: 0xc52b, Name: "Unifying Receiver", VendorName: "Logitech, Inc.", Interfaces: map[uint8]string{0: "Keyboard", 2: "Mouse"}}
+	if !ok || !reflect.DeepEqual(product, want) {
+		t.Errorf("product 046d:c52b is %+v, want %+v", product, want)
+	}
+	if _, ok := database.LookupProduct(0x1d6b, 0xc52b); ok {
+		t.Error("found a product under the wrong vendor")
+	}
+	if class := database.Classes[0x03]; class == nil || class.Subclasses[0x01].Protocols[0x02] != "Mouse" || len(class.Subclasses) != 2 {
+		t.Errorf("class 03 is %+v", class)
+	}
+	if page := database.UsagePages[0x01]; page == nil || page.Usages[0x006] != "Keyboard" || len(page.Usages) != 3 {
+		t.Errorf("usage page 01 is %+v", page)
+	}
+	if !reflect.DeepEqual(database.CountryCodes, map[uint8]string{0: "Not supported", 33: "US"}) {
+		t.Errorf("country codes are %v", database.CountryCodes)
+	}
+	if len(database.Vendors) != 2 || len(database.Classes) != 2 || len(database.UsagePages) != 1 {
+		t.Errorf("parsed the skipped sections: %d vendors, %d classes, %d usage pages",
+			len(database.Vendors), len(database.Classes), len(database.UsagePages))
+	}
+	if products := database.Search("logitech receiver"); len(products) != 1 || products[0].ID != 0xc52b {
+		t.Errorf("search found %+v", products)
+	}
+}
+
+func TestWriteToRoundTrip(t *testing.T) {
+	database, err := Load("testdata/usb.ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	count, err := database.WriteTo(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != fixtureIds {
+		t.Errorf("wrote:\n%s\nwant:\n%s", out.String(), fixtureIds)
+	}
+	if count != int64(out.Len()) {
+		t.Errorf("counted %d bytes, wrote %d", count, out.Len())
+	}
+	reparsed, err := Parse(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reparsed.Vendors, database.Vendors) || !reflect.DeepEqual(reparsed.Classes, database.Classes) ||
+		!reflect.DeepEqual(reparsed.UsagePages, database.UsagePages) || !reflect.DeepEqual(reparsed.CountryCodes, database.CountryCodes) {
+		t.Error("parsing the written database gave a different one")
+	}
+}
+
+// TestEmbeddedIsWriteToOutput checks the embedded ids are what the generator
+// writes, so regenerating them from themselves changes nothing.
+func TestEmbeddedIsWriteToOutput(t *testing.T) {
+	reader, err := gzip.NewReader(bytes.NewReader(embeddedIds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	embeddedText, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := Embedded().WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), embeddedText) {
+		t.Error("writing the embedded ids back changed them")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, test := range []struct {
+		ids string
+		err string
+	}{
+		{"046d Logitech\n\tzzzz  Bad\n", `[error] usb.ids line 2: invalid id "zzzz"`},
+		{"046d\n", `[error] usb.ids line 1: expected an id and a name, found "046d"`},
+		{"\tc52b  Orphan\n", "[error] usb.ids line 1: product outside of a vendor"},
+		{"046d  Logitech\n\t\t00  Keyboard\n", "[error] usb.ids line 2: interface outside of a product"},
+		{"C 03  HID\n\t\t01  Keyboard\n", "[error] usb.ids line 2: protocol outside of a subclass"},
+		{"C 100  Too Big\n", `[error] usb.ids line 1: invalid id "100"`},
+		{"HCC xx  Nowhere\n", `[error] usb.ids line 1: invalid country code "xx  Nowhere"`},
+	} {
+		if _, err := Parse(strings.NewReader(test.ids)); err == nil || err.Error() != test.err {
+			t.Errorf("%q: parsed with %v, want %v", test.ids, err, test.err)
+		}
+	}
+}